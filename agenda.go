@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles
+var (
+	agendaTitleStyle = lipgloss.NewStyle().
+				Background(grey).
+				Foreground(highlightColor).
+				Padding(0, 1).
+				MarginBottom(1)
+	agendaProjectStyle = lipgloss.NewStyle().
+				Bold(true).
+				MarginTop(1)
+	agendaItemStyle = lipgloss.NewStyle().
+			PaddingLeft(2)
+	agendaSelectedStyle = agendaItemStyle.Copy().
+				Foreground(highlightColor).
+				Bold(true)
+)
+
+// The "My Day" view: in progress and due tasks from every open project,
+// grouped by project.
+type Agenda struct {
+	session  *Session
+	tasks    []Task
+	projects map[int]Project
+	cursor   int
+	conflict *Task // Our move of a task someone else changed first
+	confirm  Confirm
+	revision int   // Revision of the data the agenda was loaded at
+	err      error // Why the agenda couldn't be loaded, reported when it opens
+	help     help.Model
+	keys     agendaKeyMap
+	width    int
+	height   int
+}
+
+func NewAgenda(session *Session, width, height int) *Agenda {
+	a := &Agenda{
+		session: session,
+		help:    help.New(),
+		keys:    agendaKeys,
+		width:   width,
+		height:  height,
+	}
+
+	a.err = a.load()
+
+	return a
+}
+
+// Read the agenda tasks and their projects from the db
+func (a *Agenda) load() error {
+	a.revision = currentRevision()
+
+	tasks, err := store.GetAgenda()
+	if err != nil {
+		return err
+	}
+
+	projectList, err := store.GetProjectsByStatus(open)
+	if err != nil {
+		return err
+	}
+
+	a.tasks = tasks
+	a.projects = make(map[int]Project)
+	for _, p := range projectList {
+		a.projects[p.id] = p
+	}
+
+	if a.cursor >= len(a.tasks) {
+		a.cursor = max(len(a.tasks)-1, 0)
+	}
+
+	return nil
+}
+
+func (a *Agenda) selected() (Task, bool) {
+	if len(a.tasks) == 0 {
+		return Task{}, false
+	}
+
+	return a.tasks[a.cursor], true
+}
+
+func (a *Agenda) Init() tea.Cmd {
+	if a.err != nil {
+		return reportError(a.err)
+	}
+
+	return nil
+}
+
+func (a *Agenda) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		a.width = msg.Width
+		a.height = msg.Height
+	case RevisionMsg:
+		if msg.revision != a.revision {
+			if err := a.load(); err != nil {
+				return a, reportError(err)
+			}
+		}
+	case agendaMovedMsg:
+		// Tasks that are now done drop off the agenda
+		if err := a.load(); err != nil {
+			return a, reportError(err)
+		}
+	case ConflictMsg:
+		// Show the others' change, and ask what to do with ours
+		a.conflict = &msg.task
+		if err := a.load(); err != nil {
+			return a, reportError(err)
+		}
+	case tea.KeyMsg:
+		if a.confirm.Open() {
+			var cmd tea.Cmd
+			a.confirm, cmd = a.confirm.Update(msg)
+			return a, cmd
+		}
+
+		if a.conflict != nil {
+			return a.updateConflict(msg)
+		}
+
+		switch {
+		case key.Matches(msg, a.keys.Quit):
+			return a, tea.Quit
+		case key.Matches(msg, a.keys.Up):
+			if a.cursor > 0 {
+				a.cursor--
+			}
+		case key.Matches(msg, a.keys.Down):
+			if a.cursor < len(a.tasks)-1 {
+				a.cursor++
+			}
+		case key.Matches(msg, a.keys.Help):
+			a.help.ShowAll = !a.help.ShowAll
+		case key.Matches(msg, a.keys.Move):
+			task, ok := a.selected()
+			if !ok {
+				return a, nil
+			}
+
+			var cmd tea.Cmd
+			a.confirm, cmd = confirmMove(task, moveAgendaTask(task))
+			return a, cmd
+		case key.Matches(msg, a.keys.View):
+			task, ok := a.selected()
+			if !ok {
+				return a, nil
+			}
+
+			return a, Push(NewViewTask(a.session, a.width, a.height, task))
+		case key.Matches(msg, a.keys.Board):
+			task, ok := a.selected()
+			if !ok {
+				return a, nil
+			}
+
+			b := NewBoard(a.session, task.ProjectId, a.width, a.height)
+			b.SelectTask(task)
+
+			return a, Push(b)
+		case key.Matches(msg, a.keys.Projects):
+			return a, tea.Sequence(Pop(), a.RefreshProjects)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *Agenda) updateConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return a, tea.Quit
+	case "o":
+		// A zero version skips the check
+		task := *a.conflict
+		task.Version = 0
+
+		if _, err := saveTask(task); err != nil {
+			return a, reportError(err)
+		}
+
+		a.conflict = nil
+		if err := a.load(); err != nil {
+			return a, reportError(err)
+		}
+	case "r", "esc":
+		// Their change is already showing
+		a.conflict = nil
+	}
+
+	return a, nil
+}
+
+// Sent once a task on the agenda is moved on to its next status
+type agendaMovedMsg struct{}
+
+func moveAgendaTask(task Task) tea.Cmd {
+	return func() tea.Msg {
+		_, err := store.NextStatus(task)
+		if errors.Is(err, ErrConflict) {
+			task.Next()
+			return ConflictMsg{task: task}
+		}
+
+		if err != nil {
+			return ErrorMsg{err: err}
+		}
+
+		return agendaMovedMsg{}
+	}
+}
+
+// Describe the status and due date of a task on the agenda
+func agendaTaskInfo(t Task) string {
+	info := t.Status.String()
+
+	switch {
+	case t.Overdue():
+		info += " · " + errorStyle.Render("due "+targetDateView(t.Due))
+	case !t.Due.IsZero():
+		info += " · due " + targetDateView(t.Due)
+	}
+
+	return helpStyle.Render(info)
+}
+
+func (a *Agenda) View() string {
+	if a.conflict != nil {
+		return conflictView(*a.conflict, a.width, a.height)
+	}
+
+	view := a.agendaView()
+	if a.confirm.Open() {
+		return a.confirm.View(view, a.width, a.height)
+	}
+
+	return view
+}
+
+func (a *Agenda) agendaView() string {
+	var lines []string
+	cursorLine := 0
+	lastProject := -1
+
+	for i, task := range a.tasks {
+		if task.ProjectId != lastProject {
+			p := a.projects[task.ProjectId]
+			header := agendaProjectStyle.Foreground(projectColor(p.color)).Render(p.name)
+			lines = append(lines, strings.Split(header, "\n")...)
+			lastProject = task.ProjectId
+		}
+
+		style := agendaItemStyle
+		if i == a.cursor {
+			style = agendaSelectedStyle
+			cursorLine = len(lines)
+		}
+
+		item := fmt.Sprintf("%s %s  %s", task.Key(), task.Name, agendaTaskInfo(task))
+		lines = append(lines, style.Render(item))
+	}
+
+	if len(a.tasks) == 0 {
+		lines = append(lines, helpStyle.Render("Nothing in progress or due today."))
+	}
+
+	title := agendaTitleStyle.Render("My Day")
+	h := helpStyle.Render(a.help.View(a.keys))
+
+	// Only show as many lines as fit, keeping the cursor in view
+	available := a.height - lipgloss.Height(title) - lipgloss.Height(h) - 1
+	if available > 0 && len(lines) > available {
+		start := max(0, min(cursorLine-available/2, len(lines)-available))
+		lines = lines[start : start+available]
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
+
+	body := lipgloss.JoinVertical(lipgloss.Left, title, content)
+	body = lipgloss.PlaceVertical(max(available, 0)+lipgloss.Height(title), lipgloss.Top, body)
+
+	return lipgloss.JoinVertical(lipgloss.Left, body, h)
+}
+
+func (a *Agenda) RefreshProjects() tea.Msg {
+	return RefreshProjectsMsg{}
+}
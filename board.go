@@ -1,7 +1,11 @@
 package main
 
 import (
-	"log"
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -9,26 +13,52 @@ import (
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
 )
 
+// Long task names are cut short next to the progress bar
+const maxTimerNameWidth = 30
+
 // Styles
 var (
 	helpStyle = lipgloss.NewStyle().
 			Foreground(grey)
 	progressStyle = lipgloss.NewStyle().
 			Margin(1)
+	boardHeaderStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("0")).
+				Padding(0, 1)
+	timerStyle = lipgloss.NewStyle().
+			Foreground(highlightColor)
 )
 
 // This is the model for the board view, which will implement the
 // Bubbletea model methods for rendering etc. It maintains multiple
 // lists components from bubbles, and is styled via lipgloss.
 type Board struct {
+	session        *Session
 	focused        status
 	lanes          []SwimLane
-	err            error
+	err            error // Why the board couldn't be loaded, reported when it opens
 	loaded         bool
 	quitting       bool
 	project        int
+	details        Project
+	filter         TaskFilter
+	sprint         Sprint          // The sprint the board is showing, when filtered to one
+	epic           Task            // The epic the board is showing, when filtered to one
+	grouping       grouping        // What the rows are split by, if anything
+	collapsed      map[string]bool // Keys of the rows that are folded
+	group          string          // Key of the row the cursor is in
+	notice         string
+	revision       int   // Revision of the data the lanes were loaded at
+	conflict       *Task // Our change to a task someone else changed first
+	confirm        Confirm
+	marked         map[int]bool // Ids of tasks marked for a bulk change
+	bulk           BulkMenu
+	timer          TimeEntry // The user's running timer, on any project, if any
+	byPoints       bool      // Progress counts estimates rather than tasks
 	help           help.Model
 	keys           boardKeyMap
 	height         int
@@ -51,22 +81,31 @@ func resetListHeight() tea.Msg {
 	return &ResetListHeightMsg{}
 }
 
-func NewBoard(project int, width int, height int) *Board {
+func NewBoard(session *Session, project int, width int, height int) *Board {
 	b := &Board{
-		project:  project,
-		keys:     boardKeys,
-		help:     help.New(),
-		progress: progress.New(progress.WithScaledGradient(secondary, highlight)),
-		width:    width,
-		height:   height,
-		focused:  todo,
-		loaded:   true,
+		session:   session,
+		project:   project,
+		keys:      boardKeys,
+		help:      help.New(),
+		progress:  progress.New(progress.WithScaledGradient(secondary, highlight)),
+		width:     width,
+		height:    height,
+		focused:   todo,
+		loaded:    true,
+		marked:    make(map[int]bool),
+		collapsed: make(map[string]bool),
 	}
 
-	b.initLists(width, height)
+	details, err := store.GetProject(project)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		b.err = err
+	}
+	b.details = details
 
-	// Focus the todo lane
-	b.lanes[todo].Focus()
+	// The todo lane starts focused
+	if err := b.initLists(width, height); err != nil && b.err == nil {
+		b.err = err
+	}
 
 	return b
 }
@@ -95,6 +134,40 @@ func (m *Board) Prev() {
 	m.lanes[m.focused].Focus()
 }
 
+// Focus the lane of the given task and put the cursor on it
+func (m *Board) SelectTask(task Task) {
+	m.lanes[m.focused].Blur()
+	m.focused = task.Status
+	m.lanes[m.focused].Focus()
+
+	if m.grouping != groupNone {
+		m.group = groupsOf(task, m.grouping)[0].key
+	}
+
+	for i, item := range m.lanes[m.focused].list.Items() {
+		if item.(Task).Id == task.Id {
+			m.lanes[m.focused].list.Select(i)
+			break
+		}
+	}
+}
+
+// Moving on from a task that's still waiting on others is allowed, but not
+// by accident, so it's asked about first. This is a warning rather than a
+// confirmation, so skip_confirmations doesn't turn it off.
+func confirmMove(task Task, move tea.Cmd) (Confirm, tea.Cmd) {
+	if !task.Blocked() {
+		return Confirm{}, move
+	}
+
+	prompt := fmt.Sprintf("%s is blocked by %s that aren't done. Move it anyway?", task.Key(), taskCount(task.OpenBlockers))
+	if task.OpenBlockers == 1 {
+		prompt = fmt.Sprintf("%s is blocked by a task that isn't done. Move it anyway?", task.Key())
+	}
+
+	return Confirm{prompt: prompt, action: move}, nil
+}
+
 func (m *Board) MoveToNext() tea.Msg {
 	// First, get the focused lane and selected task
 	focusedLane := m.lanes[m.focused]
@@ -118,25 +191,34 @@ func (m *Board) MoveToNext() tea.Msg {
 		// Get the current (will be old) status
 		oldStatus := selectedTask.Status
 
+		// Increment the selected task status, via the store, first.
+		// The lanes are only changed once it has worked, so a task
+		// that couldn't be moved stays where it is.
+		updatedTask, err := store.NextStatus(selectedTask)
+		if errors.Is(err, ErrConflict) {
+			selectedTask.Next()
+			return ConflictMsg{task: selectedTask}
+		}
+
+		if err != nil {
+			return ErrorMsg{err: err}
+		}
+
 		// Remove the item from the list
 		m.lanes[oldStatus].list.RemoveItem(itemIndex)
 
 		// Put the cursor on the new selectIndex value
 		m.lanes[oldStatus].list.Select(selectIndex)
 
-		// Increment the selected task status, via the taskDB method,
-		// which should put it on the next lane in the UI AND the db.
-		taskDB := GetDB()
-		defer taskDB.db.Close()
-
-		// Before we change the status, handle changing the completed tasks
+		// Handle changing the completed tasks
 		if oldStatus == done {
 			m.completedTasks--
 		}
 
-		updatedTask, err := taskDB.NextStatus(selectedTask)
-		if err != nil {
-			log.Fatal(err)
+		// Finishing a recurring task brings round its next instance,
+		// so the whole board needs a reload
+		if updatedTask.Status == done && selectedTask.Recurrence != "" {
+			return RecurMsg{}
 		}
 
 		// Adjust completed tasks
@@ -171,16 +253,23 @@ func (m *Board) MoveToNext() tea.Msg {
 	return nil
 }
 
-func (m *Board) initLists(width, height int) {
-	todoLane := new(SwimLane)
-	inProgressLane := new(SwimLane)
-	doneLane := new(SwimLane)
+// Load the lanes from the db, and focus the current one. If anything can't
+// be read, the lanes that can are still shown, and the board tries again on
+// the next revision check.
+func (m *Board) initLists(width, height int) error {
+	// Read the revision first, so anything that changes while the
+	// lanes are loading gets picked up on the next check.
+	m.revision = currentRevision()
 
-	m.lanes = []SwimLane{
-		todoLane.Init(width, m.getListHeight(height), m.project, todo),
-		inProgressLane.Init(width, m.getListHeight(height), m.project, inProgress),
-		doneLane.Init(width, m.getListHeight(height), m.project, done),
-	}
+	todoLane, todoErr := new(SwimLane).Init(width, m.getListHeight(height), m.details, todo, m.filter, m.marked)
+	inProgressLane, inProgressErr := new(SwimLane).Init(width, m.getListHeight(height), m.details, inProgress, m.filter, m.marked)
+	doneLane, doneErr := new(SwimLane).Init(width, m.getListHeight(height), m.details, done, m.filter, m.marked)
+
+	m.lanes = []SwimLane{todoLane, inProgressLane, doneLane}
+	m.lanes[m.focused].Focus()
+
+	timer, timerErr := runningTimer(m.session.user.id)
+	m.timer = timer
 
 	// Count total and completed tasks for the progress bar.
 	m.totalTasks = 0
@@ -191,6 +280,57 @@ func (m *Board) initLists(width, height int) {
 			m.completedTasks = len(lane.list.Items())
 		}
 	}
+
+	if err := cmp.Or(todoErr, inProgressErr, doneErr, timerErr); err != nil {
+		m.revision = -1
+		return err
+	}
+
+	return nil
+}
+
+// The task under the cursor, nil if there isn't one
+func (m Board) selectedItem() list.Item {
+	if m.grouping == groupNone {
+		return m.lanes[m.focused].list.SelectedItem()
+	}
+
+	groups := m.groups()
+	if _, task := m.groupCursor(groups); task != 0 {
+		return m.lanes[m.focused].list.SelectedItem()
+	}
+
+	return nil
+}
+
+// Reload the lanes from the db, keeping the selected task in each lane
+// where possible.
+func (m *Board) reload() error {
+	selected := make([]int, len(m.lanes))
+	indexes := make([]int, len(m.lanes))
+	for i, lane := range m.lanes {
+		if item := lane.list.SelectedItem(); item != nil {
+			selected[i] = item.(Task).Id
+		}
+		indexes[i] = lane.list.Index()
+	}
+
+	err := m.initLists(m.width, m.height)
+
+	for i := range m.lanes {
+		items := m.lanes[i].list.Items()
+		index := min(indexes[i], max(len(items)-1, 0))
+		for j, item := range items {
+			if item.(Task).Id == selected[i] {
+				index = j
+				break
+			}
+		}
+
+		m.lanes[i].list.Select(index)
+	}
+
+	return err
 }
 
 // This will return a height minus the height of other UI elements
@@ -200,10 +340,53 @@ func (m *Board) getListHeight(height int) int {
 	helpHeight := lipgloss.Height(m.help.View(boardKeys))
 	progressHeight := lipgloss.Height(m.progress.ViewAs(0.0))
 	progressMargin := 1
-	return height - helpHeight - progressHeight - progressMargin*2
+	headerHeight := lipgloss.Height(m.headerView())
+	return height - headerHeight - helpHeight - progressHeight - progressMargin*2
+}
+
+// The project name in the project's color, followed by its other details
+func (m *Board) headerView() string {
+	name := boardHeaderStyle.
+		Background(projectColor(m.details.color)).
+		Render(m.details.name)
+
+	var extra string
+	if m.details.owner != "" {
+		extra += " · " + m.details.owner
+	}
+
+	if !m.details.targetDate.IsZero() {
+		extra += fmt.Sprintf(" · due %s", targetDateView(m.details.targetDate))
+	}
+
+	if m.filter.Assignee != 0 {
+		extra += " · only mine"
+	}
+
+	if m.grouping != groupNone {
+		extra += " · by " + m.grouping.String()
+	}
+
+	if m.filter.Sprint != 0 {
+		extra += fmt.Sprintf(" · %s, ends %s", m.sprint.Name, m.sprint.End.Format("2 Jan"))
+	}
+
+	if m.filter.Epic != 0 {
+		extra += fmt.Sprintf(" · epic %s %s", m.epic.Key(), m.epic.Name)
+	}
+
+	if m.notice != "" {
+		extra += " · " + m.notice
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, name, helpStyle.Render(extra))
 }
 
 func (m Board) Init() tea.Cmd {
+	if m.err != nil {
+		return reportError(m.err)
+	}
+
 	return nil
 }
 
@@ -212,10 +395,86 @@ func (m Board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.initLists(msg.Width, msg.Height)
-		m.lanes[m.focused].Focus()
 		m.progress.Width = (msg.Width / 2) - horizontalPad*2
+		if err := m.initLists(msg.Width, msg.Height); err != nil {
+			return m, reportError(err)
+		}
+	case RevisionMsg:
+		if msg.revision != m.revision {
+			if err := m.reload(); err != nil {
+				return m, reportError(err)
+			}
+		}
+
+		return m, nil
+	case RecurMsg:
+		if err := m.reload(); err != nil {
+			return m, reportError(err)
+		}
+
+		return m, nil
+	case DeleteTaskMsg:
+		// Counts and all
+		delete(m.marked, msg.task.Id)
+		if err := m.reload(); err != nil {
+			return m, reportError(err)
+		}
+
+		return m, nil
+	case BulkChangeMsg:
+		// Every bulk change asks first, since it can touch many tasks
+		var cmd tea.Cmd
+		m.confirm, cmd = Ask(msg.prompt, updateTasks(m.bulkIds(), msg.change))
+
+		return m, cmd
+	case MoveTaskMsg:
+		if !msg.duplicate {
+			delete(m.marked, msg.task.Id)
+		}
+
+		if err := m.reload(); err != nil {
+			return m, reportError(err)
+		}
+
+		return m, nil
+	case BulkDoneMsg:
+		clear(m.marked)
+		if err := m.reload(); err != nil {
+			return m, reportError(err)
+		}
+
+		return m, nil
+	case ConflictMsg:
+		// Show the others' change, and ask what to do with ours
+		m.conflict = &msg.task
+		if err := m.reload(); err != nil {
+			return m, reportError(err)
+		}
+
+		return m, nil
 	case tea.KeyMsg:
+		if m.confirm.Open() {
+			var cmd tea.Cmd
+			m.confirm, cmd = m.confirm.Update(msg)
+			return m, cmd
+		}
+
+		if m.bulk.Open() {
+			var cmd tea.Cmd
+			m.bulk, cmd = m.bulk.Update(msg)
+			return m, cmd
+		}
+
+		if m.conflict != nil {
+			return m.updateConflict(msg)
+		}
+
+		m.notice = ""
+
+		if m.grouping != groupNone && m.moveInGroups(msg) {
+			return m, nil
+		}
+
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			m.quitting = true
@@ -237,7 +496,14 @@ func (m Board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.Next()
 			}
 		case key.Matches(msg, m.keys.Move):
-			return m, m.MoveToNext
+			selected := m.selectedItem()
+			if selected == nil {
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.confirm, cmd = confirmMove(selected.(Task), m.MoveToNext)
+			return m, cmd
 		case key.Matches(msg, m.keys.Help):
 			m.help.ShowAll = !m.help.ShowAll
 			model, _ := m.help.Update(nil)
@@ -254,51 +520,226 @@ func (m Board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			return m, tea.Batch(cmds...)
 		case key.Matches(msg, m.keys.New):
-			models[board] = m // save current model
-			models[form] = NewForm(m.width, m.height, m.focused, m.project)
+			// New tasks go into the sprint being shown, so they stay
+			// on the board
+			form := NewForm(m.session, m.width, m.height, m.focused, m.project)
+			form.sprint = m.filter.Sprint
 
-			return models[form], nil
+			return m, Push(form)
 		case key.Matches(msg, m.keys.Edit):
-			models[board] = m // save current model
-			currentTask := m.lanes[m.focused].list.SelectedItem().(Task)
+			selected := m.selectedItem()
+			if selected == nil {
+				return m, nil
+			}
+
 			currentIndex := m.lanes[m.focused].list.Index()
-			models[form] = UpdateForm(currentTask, currentIndex)
 
-			return models[form], nil
+			return m, Push(UpdateForm(m.session, selected.(Task), currentIndex, m.width, m.height))
 		case key.Matches(msg, m.keys.View):
-			models[board] = m // save current model
-			currentTask := m.lanes[m.focused].list.SelectedItem().(Task)
-			models[viewTask] = NewViewTask(m.width, m.height, currentTask)
+			selected := m.selectedItem()
+			if selected == nil {
+				return m, nil
+			}
 
-			return models[viewTask], nil
+			return m, Push(NewViewTask(m.session, m.width, m.height, selected.(Task)))
 		case key.Matches(msg, m.keys.Delete):
-			// We could do a confirmation screen, but for now just delete.
-			// Another option would be to archive items that are in the done
-			// column. Maybe a feature for when I'm using persistant storage.
-			// Remove from DB
-			taskDB := GetDB()
-			defer taskDB.db.Close()
+			selected := m.selectedItem()
+			if selected == nil {
+				return m, nil
+			}
+
+			task := selected.(Task)
+			prompt := fmt.Sprintf("Delete %s %s?", task.Key(), task.Name)
+
+			var cmd tea.Cmd
+			m.confirm, cmd = Ask(prompt, deleteTask(task))
+			return m, cmd
+		case key.Matches(msg, m.keys.Mark):
+			selected := m.selectedItem()
+			if selected == nil {
+				return m, nil
+			}
+
+			id := selected.(Task).Id
+			if m.marked[id] {
+				delete(m.marked, id)
+			} else {
+				m.marked[id] = true
+			}
+
+			// On to the next, so a run of tasks is quick to mark
+			if m.grouping != groupNone {
+				m.moveGroupCursor(1)
+			} else {
+				m.lanes[m.focused].list.CursorDown()
+			}
+		case key.Matches(msg, m.keys.Unmark):
+			clear(m.marked)
+		case key.Matches(msg, m.keys.Bulk):
+			ids := m.bulkIds()
+			if len(ids) == 0 {
+				return m, nil
+			}
+
+			m.bulk = NewBulkMenu(len(ids), m.session.user.id, m.project)
+		case key.Matches(msg, m.keys.MoveTo), key.Matches(msg, m.keys.CopyTo):
+			selected := m.selectedItem()
+			if selected == nil {
+				return m, nil
+			}
+
+			task := selected.(Task)
+			duplicate := key.Matches(msg, m.keys.CopyTo)
+
+			title := "Move " + task.Key() + " to"
+			if duplicate {
+				title = "Copy " + task.Key() + " to"
+			}
 
-			currentList := m.lanes[m.focused]
-			task := currentList.list.SelectedItem().(Task)
+			user := m.session.user.id
+			picker := NewProjectPicker(title, m.width, m.height, m.project, func(p Project) tea.Cmd {
+				return moveTask(task, p, duplicate, user)
+			})
 
-			err := taskDB.Delete(task.Id)
+			return m, Push(picker)
+		case key.Matches(msg, m.keys.Projects):
+			// Back to the projects view, or wherever the board was
+			// opened from
+			return m, tea.Sequence(Pop(), m.RefreshProjects)
+		case key.Matches(msg, m.keys.Assign):
+			selected := m.selectedItem()
+			if selected == nil {
+				return m, nil
+			}
+
+			// Toggle between assigned to me and unassigned
+			task := selected.(Task)
+			assignee := m.session.user.id
+			if task.Assignee == m.session.user.id {
+				assignee = 0
+			}
+
+			err := store.AssignTask(task.Id, assignee)
 			if err != nil {
-				log.Fatal(err)
+				return m, reportError(err)
 			}
 
-			m.totalTasks--
-			if task.Status == done {
-				m.completedTasks--
+			// Unassigned tasks drop out of "only mine"
+			if m.filter.Assignee != 0 {
+				if err := m.initLists(m.width, m.height); err != nil {
+					return m, reportError(err)
+				}
+
+				return m, nil
+			}
+
+			task, err = store.GetTask(task.Id)
+			if err != nil {
+				return m, reportError(err)
 			}
 
-			// And remove from UI
 			i := m.lanes[m.focused].list.Index()
-			m.lanes[m.focused].list.RemoveItem(i)
-			return m, nil
-		case key.Matches(msg, m.keys.Projects):
-			// Back to the projects view
-			return models[projects], m.RefreshProjects
+			return m, m.lanes[m.focused].list.SetItem(i, task)
+		case key.Matches(msg, m.keys.Timer):
+			// Stops the timer when it's on the selected task, or there
+			// is none, and otherwise moves it to the selected task
+			selected := m.selectedItem()
+
+			var err error
+			if selected == nil || selected.(Task).Id == m.timer.TaskId {
+				if m.timer.Id == 0 {
+					return m, nil
+				}
+
+				_, err = store.StopTimer(m.session.user.id)
+				m.timer = TimeEntry{}
+			} else {
+				m.timer, err = store.StartTimer(selected.(Task).Id, m.session.user.id)
+			}
+
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return m, reportError(err)
+			}
+		case key.Matches(msg, m.keys.Progress):
+			m.byPoints = !m.byPoints
+		case key.Matches(msg, m.keys.Burndown):
+			return m, Push(NewBurndown(m.session, m.details, m.byPoints, m.width, m.height))
+		case key.Matches(msg, m.keys.OnlyMine):
+			if m.filter.Assignee == 0 {
+				m.filter.Assignee = m.session.user.id
+			} else {
+				m.filter.Assignee = 0
+			}
+
+			if err := m.initLists(m.width, m.height); err != nil {
+				return m, reportError(err)
+			}
+		case key.Matches(msg, m.keys.SprintOnly):
+			sprints, err := store.GetSprints(m.project)
+			if err != nil {
+				return m, reportError(err)
+			}
+
+			if m.filter.Sprint != 0 {
+				m.filter.Sprint = 0
+			} else if sprint, ok := currentSprint(sprints); ok {
+				m.filter.Sprint = sprint.Id
+				m.sprint = sprint
+			} else {
+				m.notice = "No sprint has started yet"
+				return m, nil
+			}
+
+			if err := m.initLists(m.width, m.height); err != nil {
+				return m, reportError(err)
+			}
+		case key.Matches(msg, m.keys.EpicOnly):
+			// The selected epic, or the one the selected task is in
+			selected := m.selectedItem()
+			switch {
+			case m.filter.Epic != 0:
+				m.filter.Epic = 0
+			case selected != nil && selected.(Task).Epic:
+				m.epic = selected.(Task)
+				m.filter.Epic = m.epic.Id
+			case selected != nil && selected.(Task).ParentId != 0:
+				epic, err := store.GetTask(selected.(Task).ParentId)
+				if err != nil {
+					return m, reportError(err)
+				}
+
+				m.epic = epic
+				m.filter.Epic = epic.Id
+			default:
+				m.notice = "Select an epic, or a task in one"
+				return m, nil
+			}
+
+			if err := m.initLists(m.width, m.height); err != nil {
+				return m, reportError(err)
+			}
+		case key.Matches(msg, m.keys.Group):
+			// On to the next way of splitting the rows, starting with
+			// the cursor in the row of the selected task
+			m.grouping = (m.grouping + 1) % numGroupings
+			clear(m.collapsed)
+
+			m.group = ""
+			if selected := m.lanes[m.focused].list.SelectedItem(); selected != nil && m.grouping != groupNone {
+				m.group = groupsOf(selected.(Task), m.grouping)[0].key
+			}
+		case key.Matches(msg, m.keys.Fold):
+			if m.grouping == groupNone {
+				return m, nil
+			}
+
+			groups := m.groups()
+			if row, _ := m.groupCursor(groups); len(groups) > 0 {
+				group := groups[row].key
+				m.collapsed[group] = !m.collapsed[group]
+			}
+		case key.Matches(msg, m.keys.Sprints):
+			return m, Push(NewSprints(m.session, m.details, m.width, m.height))
 		}
 	case CreateTaskMsg:
 		task := msg.task
@@ -314,6 +755,17 @@ func (m Board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		task := msg.task
 		i := msg.index
 
+		// A task that changed lanes is reloaded into its new one
+		if task.Status != msg.from {
+			err := m.initLists(m.width, m.height)
+			m.SelectTask(task)
+			if err != nil {
+				return m, reportError(err)
+			}
+
+			return m, nil
+		}
+
 		// Update in list
 		return m, m.lanes[task.Status].list.SetItem(i, task)
 	case UpdateListMsg:
@@ -330,11 +782,64 @@ func (m Board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m Board) updateConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "o":
+		// A zero version skips the check
+		task := *m.conflict
+		task.Version = 0
+
+		if _, err := saveTask(task); err != nil {
+			return m, reportError(err)
+		}
+
+		m.conflict = nil
+		if err := m.reload(); err != nil {
+			return m, reportError(err)
+		}
+	case "r", "esc":
+		// Their change is already showing
+		m.conflict = nil
+	}
+
+	return m, nil
+}
+
+// Asks whether to overwrite someone else's change to the task with ours
+func conflictView(task Task, width, height int) string {
+	prompt := fmt.Sprintf(
+		"%s %s was changed by someone else.\n\n(o) overwrite with your change  (r) keep theirs",
+		task.Key(),
+		task.Name,
+	)
+	render := newProjectStyle.Align(lipgloss.Center).Render(prompt)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, render)
+}
+
 func (m Board) View() string {
+	view := m.boardView()
+	if m.confirm.Open() {
+		return m.confirm.View(view, m.width, m.height)
+	}
+
+	if m.bulk.Open() {
+		return overlay(view, m.bulk.View(), m.width, m.height)
+	}
+
+	return view
+}
+
+func (m Board) boardView() string {
 	if m.quitting {
 		return "Quitting KanBan CLI..."
 	}
 
+	if m.conflict != nil {
+		return conflictView(*m.conflict, m.width, m.height)
+	}
+
 	if m.loaded {
 		todoView := m.lanes[todo].View()
 		ipView := m.lanes[inProgress].View()
@@ -347,17 +852,153 @@ func (m Board) View() string {
 			doneView,
 		)
 
+		if m.grouping != groupNone {
+			listsView = m.groupedView(m.getListHeight(m.height))
+		}
+
 		return lipgloss.JoinVertical(
 			lipgloss.Center,
+			m.headerView(),
 			listsView,
 			helpStyle.Render(m.help.View(m.keys)),
-			progressStyle.Render(m.progress.ViewAs(float64(m.completedTasks)/float64(m.totalTasks))),
+			m.footerView(),
 		)
 	} else {
 		return "loading..."
 	}
 }
 
+// The progress bar, with the running timer next to it
+func (m Board) footerView() string {
+	bar := progressStyle.Render(m.progress.ViewAs(float64(m.completedTasks) / float64(m.totalTasks)))
+
+	if m.byPoints {
+		var points, completed float64
+		for _, lane := range m.lanes {
+			total, _ := totalEstimate(lane.Tasks())
+			points += total
+
+			if lane.laneStatus == done {
+				completed = total
+			}
+		}
+
+		var percent float64
+		if points > 0 {
+			percent = completed / points
+		}
+
+		label := helpStyle.Render(strconv.FormatFloat(completed, 'f', -1, 64) + " of " + formatEstimate(points))
+		bar = lipgloss.JoinHorizontal(lipgloss.Center, progressStyle.Render(m.progress.ViewAs(percent)), label)
+	}
+
+	if m.timer.Id == 0 {
+		return bar
+	}
+
+	timer := fmt.Sprintf(
+		"⏱ %s %s · %s",
+		m.timer.TaskKey,
+		truncate.StringWithTail(m.timer.TaskName, maxTimerNameWidth, "…"),
+		formatClock(m.timer.Duration()),
+	)
+
+	return lipgloss.JoinHorizontal(lipgloss.Center, bar, timerStyle.Render(timer))
+}
+
+// Sent when a change to a task was refused because someone else changed
+// the task first. The task is our version of it.
+type ConflictMsg struct {
+	task Task
+}
+
+// The tasks a bulk change is for: the marked ones still on the board, or
+// the selected one if none are marked
+func (m Board) bulkIds() []int {
+	var ids []int
+	for _, lane := range m.lanes {
+		for _, item := range lane.list.Items() {
+			if id := item.(Task).Id; m.marked[id] {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		if selected := m.selectedItem(); selected != nil {
+			ids = append(ids, selected.(Task).Id)
+		}
+	}
+
+	return ids
+}
+
+// Sent once a bulk change is made
+type BulkDoneMsg struct{}
+
+func updateTasks(ids []int, change BulkChange) tea.Cmd {
+	return func() tea.Msg {
+		err := store.UpdateTasks(ids, change)
+		if err != nil {
+			return ErrorMsg{err: err}
+		}
+
+		return BulkDoneMsg{}
+	}
+}
+
+// Sent once a task is moved or copied to another project
+type MoveTaskMsg struct {
+	task      Task
+	project   Project
+	duplicate bool
+}
+
+// Move the task to another project, or copy it there. Moving gives it the
+// next key in that project. Every project has the same lanes, so the task
+// stays in the one it's in.
+func moveTask(task Task, project Project, duplicate bool, user int) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if duplicate {
+			_, err = store.InsertTask(Task{
+				Name:      task.Name,
+				Info:      task.Info,
+				Status:    task.Status,
+				ProjectId: project.id,
+				Due:       task.Due,
+				Assignee:  task.Assignee,
+				CreatedBy: user,
+				Priority:  task.Priority,
+				Tags:      task.Tags,
+				Estimate:  task.Estimate,
+				Epic:      task.Epic,
+				ParentId:  task.ParentId,
+			})
+		} else {
+			err = store.UpdateTasks([]int{task.Id}, BulkChange{ProjectId: project.id})
+		}
+
+		if err != nil {
+			return ErrorMsg{err: err}
+		}
+
+		return MoveTaskMsg{task: task, project: project, duplicate: duplicate}
+	}
+}
+
+// Delete the task from the db, for the board to take it off its lane
+func deleteTask(task Task) tea.Cmd {
+	return func() tea.Msg {
+		err := store.DeleteTask(task.Id)
+		if err != nil {
+			return ErrorMsg{err: err}
+		}
+
+		return DeleteTaskMsg{task: task}
+	}
+}
+
 func (b *Board) RefreshProjects() tea.Msg {
 	return RefreshProjectsMsg{}
 }
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type bulkAction int
+
+const (
+	bulkNone bulkAction = iota // Still picking one
+	bulkStatus
+	bulkDelete
+	bulkArchive
+	bulkTag
+	bulkAssign
+	bulkPriority
+	bulkProject
+	bulkSprint
+)
+
+// The key that picks each action, what it's called in the menu, and what to
+// ask for if it needs anything
+var bulkActions = []struct {
+	action bulkAction
+	key    string
+	name   string
+	prompt string
+}{
+	{bulkStatus, "s", "move to lane", "Lane (todo, in progress, done): "},
+	{bulkPriority, "p", "set priority", "Priority (none, low, medium, high): "},
+	{bulkTag, "t", "add tag", "Tag: "},
+	{bulkAssign, "u", "assign", "Assign to (user name, \"me\", or empty for nobody): "},
+	{bulkProject, "m", "move to project", "Project key prefix: "},
+	{bulkSprint, "i", "plan into sprint", "Sprint (name, \"current\", or empty for the backlog): "},
+	{bulkArchive, "a", "archive", ""},
+	{bulkDelete, "d", "delete", ""},
+}
+
+// Sent by the bulk menu with the change to make to the tasks it was for,
+// and the question to ask before making it
+type BulkChangeMsg struct {
+	change BulkChange
+	prompt string // e.g. "Move 3 tasks to done?"
+}
+
+// Picks a change to make to the marked tasks on a board, asking for whatever
+// it needs (a lane, a tag and so on). Like Confirm, the board keeps one,
+// sends it keys while it's open, and draws it over itself.
+type BulkMenu struct {
+	count   int // Number of tasks it's for, zero if closed
+	me      int // User id "me" stands for
+	project int // Project whose sprints the tasks can go into
+	action  bulkAction
+	input   textinput.Model
+	err     string
+}
+
+func NewBulkMenu(count int, me int, project int) BulkMenu {
+	return BulkMenu{count: count, me: me, project: project}
+}
+
+func (b BulkMenu) Open() bool {
+	return b.count > 0
+}
+
+func (b BulkMenu) Update(msg tea.KeyMsg) (BulkMenu, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return b, tea.Quit
+	}
+
+	if b.action == bulkNone {
+		return b.updatePick(msg)
+	}
+
+	switch msg.String() {
+	case "esc":
+		// Back to the actions
+		b.action = bulkNone
+		b.err = ""
+		return b, nil
+	case "enter":
+		change, err := b.change(strings.TrimSpace(b.input.Value()))
+		if err != nil {
+			b.err = err.Error()
+			return b, nil
+		}
+
+		return BulkMenu{}, bulkChange(change, b.question(strings.TrimSpace(b.input.Value())))
+	}
+
+	var cmd tea.Cmd
+	b.input, cmd = b.input.Update(msg)
+
+	return b, cmd
+}
+
+func (b BulkMenu) updatePick(msg tea.KeyMsg) (BulkMenu, tea.Cmd) {
+	if msg.String() == "esc" {
+		return BulkMenu{}, nil
+	}
+
+	for _, a := range bulkActions {
+		if msg.String() != a.key {
+			continue
+		}
+
+		switch a.action {
+		case bulkArchive:
+			return BulkMenu{}, bulkChange(BulkChange{Archive: true}, "Archive "+taskCount(b.count)+"?")
+		case bulkDelete:
+			return BulkMenu{}, bulkChange(BulkChange{Delete: true}, "Delete "+taskCount(b.count)+"?")
+		}
+
+		b.action = a.action
+		b.input = textinput.New()
+		b.input.Prompt = a.prompt
+		b.input.Focus()
+
+		return b, textinput.Blink
+	}
+
+	return b, nil
+}
+
+// e.g. "1 task" or "3 tasks"
+func taskCount(n int) string {
+	if n == 1 {
+		return "1 task"
+	}
+
+	return fmt.Sprintf("%d tasks", n)
+}
+
+func bulkChange(change BulkChange, prompt string) tea.Cmd {
+	return func() tea.Msg {
+		return BulkChangeMsg{change: change, prompt: prompt}
+	}
+}
+
+// What to ask before making the change from what was typed in
+func (b BulkMenu) question(value string) string {
+	tasks := taskCount(b.count)
+
+	switch b.action {
+	case bulkStatus:
+		return fmt.Sprintf("Move %s to %s?", tasks, strings.ToLower(value))
+	case bulkPriority:
+		return fmt.Sprintf("Set the priority of %s to %s?", tasks, strings.ToLower(value))
+	case bulkTag:
+		return fmt.Sprintf("Tag %s with %s?", tasks, value)
+	case bulkAssign:
+		if value == "" {
+			return fmt.Sprintf("Unassign %s?", tasks)
+		}
+
+		return fmt.Sprintf("Assign %s to %s?", tasks, value)
+	case bulkProject:
+		return fmt.Sprintf("Move %s to %s?", tasks, strings.ToUpper(value))
+	case bulkSprint:
+		switch value {
+		case "":
+			return fmt.Sprintf("Put %s back in the backlog?", tasks)
+		case "current":
+			return fmt.Sprintf("Plan %s into the current sprint?", tasks)
+		}
+
+		return fmt.Sprintf("Plan %s into %s?", tasks, value)
+	}
+
+	return fmt.Sprintf("Change %s?", tasks)
+}
+
+// Make the change from what was typed in for the action
+func (b BulkMenu) change(value string) (BulkChange, error) {
+	var change BulkChange
+
+	switch b.action {
+	case bulkStatus:
+		n, err := GetStatusFromString(strings.ToLower(value))
+		if err != nil {
+			return change, errors.New("Lane must be todo, in progress or done")
+		}
+
+		st := status(n)
+		change.Status = &st
+	case bulkPriority:
+		p, err := GetPriorityFromString(strings.ToLower(value))
+		if err != nil {
+			return change, errors.New("Priority must be none, low, medium or high")
+		}
+
+		change.Priority = &p
+	case bulkTag:
+		if value == "" || strings.Contains(value, ",") {
+			return change, errors.New("Tag can't be empty or have a comma in it")
+		}
+
+		change.Tag = value
+	case bulkAssign:
+		var user int
+		switch value {
+		case "":
+		case "me":
+			user = b.me
+		default:
+			u, err := store.FindUser(value)
+			if errors.Is(err, sql.ErrNoRows) {
+				return change, fmt.Errorf("Unknown user %s", value)
+			}
+
+			if err != nil {
+				return change, err
+			}
+
+			user = u.id
+		}
+
+		change.Assignee = &user
+	case bulkProject:
+		projects, err := store.GetProjectsByStatus(open)
+		if err != nil {
+			return change, err
+		}
+
+		for _, p := range projects {
+			if strings.EqualFold(p.prefix, value) {
+				change.ProjectId = p.id
+			}
+		}
+
+		if change.ProjectId == 0 {
+			return change, fmt.Errorf("No open project has the prefix %s", value)
+		}
+	case bulkSprint:
+		sprints, err := store.GetSprints(b.project)
+		if err != nil {
+			return change, err
+		}
+
+		var sprint int
+		switch value {
+		case "":
+		case "current":
+			current, ok := currentSprint(sprints)
+			if !ok {
+				return change, errors.New("No sprint has started yet")
+			}
+
+			sprint = current.Id
+		default:
+			for _, s := range sprints {
+				if s.Open() && strings.EqualFold(s.Name, value) {
+					sprint = s.Id
+				}
+			}
+
+			if sprint == 0 {
+				return change, fmt.Errorf("No open sprint is called %s", value)
+			}
+		}
+
+		change.Sprint = &sprint
+	}
+
+	return change, nil
+}
+
+func (b BulkMenu) View() string {
+	lines := []string{"Change " + taskCount(b.count), ""}
+
+	if b.action == bulkNone {
+		for _, a := range bulkActions {
+			lines = append(lines, fmt.Sprintf("(%s) %s", a.key, a.name))
+		}
+
+		lines = append(lines, "", helpStyle.Render("esc to cancel"))
+	} else {
+		lines = append(lines, b.input.View())
+		if b.err != "" {
+			lines = append(lines, errorStyle.Render(b.err))
+		}
+
+		lines = append(lines, "", helpStyle.Render("enter to apply, esc to go back"))
+	}
+
+	return confirmStyle.Align(lipgloss.Left).Render(strings.Join(lines, "\n"))
+}
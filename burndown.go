@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultBurndownDays = 14
+	minBurndownDays     = 7
+	maxChartHeight      = 16
+)
+
+// Styles
+var (
+	chartBarStyle = lipgloss.NewStyle().
+			Foreground(secondaryColor)
+	chartLineStyle = lipgloss.NewStyle().
+			Foreground(highlightColor)
+	chartAxisStyle = lipgloss.NewStyle().
+			Foreground(grey)
+)
+
+// A burndown (or burnup) chart of a project's tasks, a day at a time over
+// a range of dates
+type Burndown struct {
+	session  *Session
+	project  Project
+	tasks    []Task // Every task on the board, in any lane
+	from     time.Time
+	to       time.Time
+	burnup   bool  // Work done against scope, rather than work left
+	byPoints bool  // Count estimates rather than tasks
+	revision int   // Revision of the data the chart was loaded at
+	err      error // Why the tasks couldn't be loaded, reported when it opens
+	help     help.Model
+	keys     burndownKeyMap
+	width    int
+	height   int
+}
+
+// The last two weeks up to today, counted the same way the board's
+// progress bar is
+func NewBurndown(session *Session, project Project, byPoints bool, width, height int) *Burndown {
+	today, _ := parseDate(time.Now().Format(dateFormat))
+
+	b := &Burndown{
+		session:  session,
+		project:  project,
+		from:     today.AddDate(0, 0, 1-defaultBurndownDays),
+		to:       today,
+		byPoints: byPoints,
+		help:     help.New(),
+		keys:     burndownKeys,
+		width:    width,
+		height:   height,
+	}
+
+	b.err = b.load()
+
+	return b
+}
+
+func (b *Burndown) load() error {
+	b.revision = currentRevision()
+	b.tasks = nil
+
+	for s := todo; s <= done; s++ {
+		tasks, err := store.GetTasksByStatus(s, b.project.id, TaskFilter{})
+		if err != nil {
+			return err
+		}
+
+		b.tasks = append(b.tasks, tasks...)
+	}
+
+	return nil
+}
+
+func (b *Burndown) Init() tea.Cmd {
+	if b.err != nil {
+		return reportError(b.err)
+	}
+
+	return nil
+}
+
+func (b *Burndown) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width = msg.Width
+		b.height = msg.Height
+	case RevisionMsg:
+		if msg.revision != b.revision {
+			if err := b.load(); err != nil {
+				return b, reportError(err)
+			}
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, b.keys.Quit):
+			return b, tea.Quit
+		case key.Matches(msg, b.keys.Back):
+			return b, Pop()
+		case key.Matches(msg, b.keys.Earlier):
+			b.from = b.from.AddDate(0, 0, -7)
+			b.to = b.to.AddDate(0, 0, -7)
+		case key.Matches(msg, b.keys.Later):
+			b.from = b.from.AddDate(0, 0, 7)
+			b.to = b.to.AddDate(0, 0, 7)
+		case key.Matches(msg, b.keys.Wider):
+			b.from = b.from.AddDate(0, 0, -7)
+		case key.Matches(msg, b.keys.Narrower):
+			if b.days() > minBurndownDays {
+				b.from = b.from.AddDate(0, 0, 7)
+			}
+		case key.Matches(msg, b.keys.Burnup):
+			b.burnup = !b.burnup
+		case key.Matches(msg, b.keys.Points):
+			b.byPoints = !b.byPoints
+		case key.Matches(msg, b.keys.Help):
+			b.help.ShowAll = !b.help.ShowAll
+		}
+	}
+
+	return b, nil
+}
+
+// Number of days in the range, counting both ends
+func (b *Burndown) days() int {
+	return daysBetween(b.from, b.to) + 1
+}
+
+// How much a task counts for in the chart
+func (b *Burndown) weight(t Task) float64 {
+	if b.byPoints {
+		return t.Estimate
+	}
+
+	return 1
+}
+
+// Scope and work done as they were at the end of each day in the range.
+// Tasks without a creation time count from the start, and done tasks
+// without a completion time count as done throughout.
+func (b *Burndown) series() (scope, completed []float64) {
+	for i := 0; i < b.days(); i++ {
+		end := b.from.AddDate(0, 0, i+1)
+
+		var s, c float64
+		for _, t := range b.tasks {
+			if !t.CreatedAt.IsZero() && !t.CreatedAt.Before(end) {
+				continue
+			}
+
+			s += b.weight(t)
+
+			if t.Status == done && (t.CompletedAt.IsZero() || t.CompletedAt.Before(end)) {
+				c += b.weight(t)
+			}
+		}
+
+		scope = append(scope, s)
+		completed = append(completed, c)
+	}
+
+	return scope, completed
+}
+
+// A number of tasks or points, for the labels
+func (b *Burndown) amount(n float64) string {
+	if b.byPoints {
+		return formatEstimate(n)
+	}
+
+	return taskCount(int(n))
+}
+
+func (b *Burndown) View() string {
+	scope, completed := b.series()
+	days := b.days()
+
+	// Days after today haven't happened yet, so they only get the line
+	past := days
+	if now := time.Now(); daysBetween(now, b.to) > 0 {
+		past = max(daysBetween(b.from, now)+1, 0)
+	}
+
+	// The ideal line runs from the scope on the first day there was any
+	// work, down to nothing on the last day
+	start := 0
+	for start < days-1 && scope[start] == 0 {
+		start++
+	}
+
+	bars := make([]float64, days)
+	line := make([]float64, days)
+	for i := range bars {
+		if b.burnup {
+			bars[i] = completed[i]
+			line[i] = scope[i]
+			continue
+		}
+
+		bars[i] = scope[i] - completed[i]
+		if i >= start && days-1 > start {
+			line[i] = scope[start] * float64(days-1-i) / float64(days-1-start)
+		}
+	}
+
+	var top float64
+	for i := range bars {
+		top = max(top, bars[i], line[i])
+	}
+
+	title, barName, lineName := "Burndown", "left", "ideal"
+	if b.burnup {
+		title, barName, lineName = "Burnup", "done", "scope"
+	}
+
+	header := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		boardHeaderStyle.Background(projectColor(b.project.color)).Render(b.project.name),
+		helpStyle.Render(fmt.Sprintf(
+			" · %s, %s to %s",
+			title,
+			b.from.Format("2 Jan"),
+			b.to.Format("2 Jan 2006"),
+		)),
+	)
+
+	var chart string
+	if top == 0 {
+		nothing := "No tasks to chart"
+		if b.byPoints {
+			nothing = "No estimated tasks to chart"
+		}
+
+		chart = helpStyle.Render(nothing)
+	} else {
+		chart = b.chartView(bars[:past], line, top)
+	}
+
+	last := max(past-1, 0)
+	summary := fmt.Sprintf(
+		"Scope %s · done %s · left %s",
+		b.amount(scope[last]),
+		b.amount(completed[last]),
+		b.amount(scope[last]-completed[last]),
+	)
+
+	legend := chartBarStyle.Render("█") + " " + barName + "  " + chartLineStyle.Render("•") + " " + lineName
+
+	render := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		"",
+		chart,
+		"",
+		summary,
+		helpStyle.Render(legend),
+		"",
+		b.help.View(b.keys),
+	)
+
+	return lipgloss.Place(b.width, b.height, lipgloss.Center, lipgloss.Center, render)
+}
+
+// Bars for each day up to today, with the line drawn over every day in
+// the range and the y axis labelled from zero to top
+func (b *Burndown) chartView(bars, line []float64, top float64) string {
+	height := min(maxChartHeight, max(b.height-12, 4))
+
+	// Wide enough for the range to fill some of the screen, but with
+	// room for the axis
+	labelWidth := len(strconv.FormatFloat(top, 'f', -1, 64))
+	colWidth := max(min((b.width-labelWidth-4)/len(line), 4), 1)
+
+	// Heights in rows, rounding so anything above zero shows
+	rows := func(v float64) int {
+		if v <= 0 {
+			return 0
+		}
+
+		return max(int(v/top*float64(height)+0.5), 1)
+	}
+
+	var lines []string
+	for r := height; r >= 1; r-- {
+		var label string
+		switch r {
+		case height:
+			label = strconv.FormatFloat(top, 'f', -1, 64)
+		case 1:
+			label = "0"
+		}
+
+		var sb strings.Builder
+		for i := range line {
+			// A gap between bars, when there's room for one
+			bar := max(colWidth-1, 1)
+			onBar := i < len(bars) && rows(bars[i]) >= r
+
+			cell := strings.Repeat(" ", colWidth)
+			switch {
+			case rows(line[i]) == r && onBar:
+				// The line shows through the bar
+				dot := chartLineStyle.Copy().Background(secondaryColor).Render("•")
+				cell = dot + chartBarStyle.Render(strings.Repeat("█", bar-1)) + strings.Repeat(" ", colWidth-bar)
+			case rows(line[i]) == r:
+				cell = chartLineStyle.Render("•") + strings.Repeat(" ", colWidth-1)
+			case onBar:
+				cell = chartBarStyle.Render(strings.Repeat("█", bar)) + strings.Repeat(" ", colWidth-bar)
+			}
+
+			sb.WriteString(cell)
+		}
+
+		lines = append(lines, chartAxisStyle.Render(fmt.Sprintf("%*s │", labelWidth, label))+sb.String())
+	}
+
+	width := colWidth * len(line)
+	axis := chartAxisStyle.Render(strings.Repeat(" ", labelWidth) + " └" + strings.Repeat("─", width))
+
+	// The first and last day under the ends of the axis
+	from := b.from.Format("2 Jan")
+	to := b.to.Format("2 Jan")
+	gap := max(width-len(from)-len(to), 1)
+	dates := chartAxisStyle.Render(strings.Repeat(" ", labelWidth+2) + from + strings.Repeat(" ", gap) + to)
+
+	return strings.Join(append(lines, axis, dates), "\n")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+
+	gap "github.com/muesli/go-app-paths"
+	"gopkg.in/yaml.v3"
+)
+
+const configName = "config.yaml"
+
+// Settings read from config.yaml in the XDG config dir, e.g.
+// ~/.config/kanban/config.yaml
+type Config struct {
+	// Who you are on a shared board. Defaults to $KANBAN_USER, and then
+	// to your login name.
+	User        string `yaml:"user"`
+	DisplayName string `yaml:"display_name"`
+
+	// Bearer token for the API served by `kanban-cli serve`, which is
+	// also sent to the server given by remote. The API won't start
+	// without one.
+	APIToken string `yaml:"api_token"`
+
+	// URL of a kanban server to use instead of the local database, the
+	// same as --remote
+	Remote string `yaml:"remote"`
+
+	// Public keys of the people who can use the board served by
+	// `kanban-cli ssh-serve`, by user name. Keys are in authorized_keys
+	// format.
+	SSHKeys map[string][]string `yaml:"ssh_keys"`
+
+	// Delete, archive and the like without asking first
+	SkipConfirmations bool `yaml:"skip_confirmations"`
+
+	// What task estimates are in, "points" (the default) or "hours"
+	EstimateUnit string `yaml:"estimate_unit"`
+}
+
+var config Config
+
+func getConfigPath() string {
+	scope := gap.NewScope(gap.User, "kanban")
+	path, err := scope.ConfigPath(configName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return path
+}
+
+// Read the config file, if there is one. A missing file is the same as an
+// empty one.
+func loadConfig() (Config, error) {
+	var c Config
+
+	data, err := os.ReadFile(getConfigPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+
+		return c, err
+	}
+
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+
+	switch c.EstimateUnit {
+	case "", pointsUnit, hoursUnit:
+	default:
+		return c, fmt.Errorf("estimate_unit must be %s or %s", pointsUnit, hoursUnit)
+	}
+
+	return c, nil
+}
+
+func (c Config) estimateUnit() string {
+	if c.EstimateUnit == "" {
+		return pointsUnit
+	}
+
+	return c.EstimateUnit
+}
+
+// The user name of whoever is running this
+func (c Config) UserName() string {
+	if c.User != "" {
+		return c.User
+	}
+
+	if u := os.Getenv("KANBAN_USER"); u != "" {
+		return u
+	}
+
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+
+	return "me"
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/reflow/ansi"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/termenv"
+)
+
+var confirmStyle = lipgloss.NewStyle().
+	Padding(1, 2).
+	Border(lipgloss.RoundedBorder(), true).
+	BorderForeground(highlightColor).
+	Align(lipgloss.Center)
+
+// Ends whatever style the text before the dialog was in
+const resetStyle = termenv.CSI + termenv.ResetSeq + "m"
+
+// A yes or no question, asked over a view before doing something that can't
+// be undone. The view keeps one, sends it keys while it's open, and draws it
+// over itself.
+type Confirm struct {
+	prompt string
+	action tea.Cmd // Run if the answer is yes
+}
+
+// Ask before running the action. With confirmations turned off in the
+// config, nothing is asked and the action is returned to run straight away.
+func Ask(prompt string, action tea.Cmd) (Confirm, tea.Cmd) {
+	if config.SkipConfirmations {
+		return Confirm{}, action
+	}
+
+	return Confirm{prompt: prompt, action: action}, nil
+}
+
+// Whether the question is waiting on an answer
+func (c Confirm) Open() bool {
+	return c.action != nil
+}
+
+func (c Confirm) Update(msg tea.KeyMsg) (Confirm, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return c, tea.Quit
+	case "y", "Y":
+		return Confirm{}, c.action
+	case "n", "N", "esc":
+		return Confirm{}, nil
+	}
+
+	return c, nil
+}
+
+// Draw the question in the middle of the view beneath it
+func (c Confirm) View(view string, width, height int) string {
+	dialog := confirmStyle.Render(c.prompt + "\n\n(y/n)")
+
+	if width == 0 || height == 0 {
+		return dialog
+	}
+
+	return overlay(view, dialog, width, height)
+}
+
+// Put fg in the middle of bg, which is made to fill the width and height
+func overlay(bg, fg string, width, height int) string {
+	bg = lipgloss.Place(width, height, lipgloss.Left, lipgloss.Top, bg)
+	rows := strings.Split(bg, "\n")
+	fgRows := strings.Split(fg, "\n")
+
+	fgWidth := lipgloss.Width(fg)
+	x := max(0, (width-fgWidth)/2)
+	y := max(0, (len(rows)-len(fgRows))/2)
+
+	for i, fgRow := range fgRows {
+		if y+i >= len(rows) {
+			break
+		}
+
+		row := rows[y+i]
+		left := truncate.String(row, uint(x))
+		left += strings.Repeat(" ", max(0, x-ansi.PrintableRuneWidth(left)))
+		right := skipCells(row, x+lipgloss.Width(fgRow))
+
+		rows[y+i] = left + resetStyle + fgRow + right
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+// The part of a rendered line after the first n cells. Escape sequences in
+// the skipped part are kept, so the rest is styled as it was.
+func skipCells(s string, n int) string {
+	var b strings.Builder
+	escaping := false
+	width := 0
+
+	for _, r := range s {
+		if r == ansi.Marker {
+			escaping = true
+		}
+
+		if escaping {
+			b.WriteRune(r)
+			if ansi.IsTerminator(r) {
+				escaping = false
+			}
+
+			continue
+		}
+
+		if width >= n {
+			b.WriteRune(r)
+			continue
+		}
+
+		// A wide character cut in half leaves a space behind
+		width += runewidth.RuneWidth(r)
+		if width > n {
+			b.WriteString(strings.Repeat(" ", width-n))
+		}
+	}
+
+	return b.String()
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	gap "github.com/muesli/go-app-paths"
@@ -13,6 +16,15 @@ import (
 const (
 	dbDriver = "sqlite3"
 	dbName   = "./kanbandb"
+
+	// Dates without a time of day are stored as text in this format
+	dateFormat = "2006-01-02"
+
+	// Timestamps are stored in UTC, the same as SQLite's datetime('now')
+	timestampFormat = "2006-01-02 15:04:05"
+
+	// How long to wait on another process holding a lock, in milliseconds
+	busyTimeout = 5000
 )
 
 // Get or Setup XDG-compliant path for SQLite DB
@@ -51,42 +63,197 @@ func initKanbanDir(path string) error {
 	return nil
 }
 
-func GetDB() TaskDB {
-    // Uncomment for local dev
-	// db, err := sql.Open(dbDriver, dbName)
+// The database file may be shared by several people and terminals at once.
+// WAL lets readers carry on while someone writes, the busy timeout makes
+// writers wait their turn instead of failing, and immediate transactions
+// take the write lock up front so two writers can't deadlock.
+func dataSourceName(path string) string {
+	return fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=%d&_txlock=immediate", path, busyTimeout)
+}
+
+// Tasks and projects live in the same database file, since tasks are
+// joined against their project (e.g. for the task key prefix).
+func openDB() *sql.DB {
+	// Uncomment for local dev
+	// path := dbName
 
-    // Comment for local dev
-	db, err := sql.Open(dbDriver, filepath.Join(getDbPath(), dbName))
+	// Comment for local dev
+	path := filepath.Join(getDbPath(), dbName)
+
+	db, err := sql.Open(dbDriver, dataSourceName(path))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Initializse our TaskDB
+	// Projects, sprints and users first, since tasks refer to them
+	p := ProjectDB{db}
+	if err := p.CreateTable(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := p.CreateSprintTable(); err != nil {
+		log.Fatal(err)
+	}
+
+	u := UserDB{db}
+	if err := u.CreateTable(); err != nil {
+		log.Fatal(err)
+	}
+
 	t := TaskDB{db}
+	if err := t.CreateTable(); err != nil {
+		log.Fatal(err)
+	}
 
-	// This will create the table if it does not exist
-	err = t.CreateTable()
-	if err != nil {
+	if err := t.CreateDependencyTable(); err != nil {
 		log.Fatal(err)
 	}
 
-	return t
-}
+	if err := t.CreateTimeTable(); err != nil {
+		log.Fatal(err)
+	}
 
-func GetProjectDB() ProjectDB {
-	db, err := sql.Open(dbDriver, dbName)
-	// db, err := sql.Open(dbDriver, filepath.Join(getDbPath(), dbName))
-	if err != nil {
+	tt := TemplateDB{db}
+	if err := tt.CreateTable(); err != nil {
 		log.Fatal(err)
 	}
 
-	p := ProjectDB{db}
+	if err := createRevision(db); err != nil {
+		log.Fatal(err)
+	}
 
-	// This will create the table if it does not exist
-	err = p.CreateTable()
+	return db
+}
+
+// Every change to tasks, projects, sprints, dependencies or timers bumps a
+// single revision number, by way of triggers, so that other processes can
+// cheaply tell that they need to reload.
+func createRevision(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS revision (
+            id INTEGER PRIMARY KEY CHECK (id = 1),
+            revision INTEGER NOT NULL DEFAULT 0
+        )`,
+		"INSERT OR IGNORE INTO revision (id, revision) VALUES (1, 0)",
+	}
+
+	for _, table := range []string{"tasks", "projects", "sprints", "task_dependencies", "time_entries"} {
+		for _, event := range []string{"INSERT", "UPDATE", "DELETE"} {
+			statements = append(statements, fmt.Sprintf(
+				`CREATE TRIGGER IF NOT EXISTS %s_%s_revision AFTER %s ON %s
+                BEGIN UPDATE revision SET revision = revision + 1 WHERE id = 1; END`,
+				table,
+				strings.ToLower(event),
+				event,
+				table,
+			))
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The current revision of the data, see createRevision
+func getRevision(db *sql.DB) (int, error) {
+	var revision int
+	err := db.QueryRow("SELECT revision FROM revision WHERE id = 1").Scan(&revision)
+
+	return revision, err
+}
+
+// CREATE TABLE IF NOT EXISTS won't touch tables made by older versions,
+// so any column added after the fact needs to go through here as well.
+// Returns true if the column was added.
+func addColumn(db *sql.DB, table, column, definition string) (bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
 	if err != nil {
-		log.Fatal(err)
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+
+		if name == column {
+			return false, nil
+		}
+	}
+	rows.Close()
+
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+
+	return err == nil, err
+}
+
+// Parse an optional date. An empty string is the zero time, meaning no date.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.ParseInLocation(dateFormat, s, time.Local)
+}
+
+// Format an optional date for storage, the zero time being NULL
+func formatDate(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t.Format(dateFormat)
+}
+
+// Parse an optional timestamp, as written by SQLite's datetime('now')
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.ParseInLocation(timestampFormat, s, time.UTC)
+	return t.Local(), err
+}
+
+// Today's date, for comparing against stored dates
+func today() string {
+	return time.Now().Format(dateFormat)
+}
+
+// The number of calendar days from a to b, negative if b is earlier. The
+// times of day don't matter, and neither do clocks changing in between.
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+
+	// There's no daylight saving in UTC, so every day is 24 hours long
+	from := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	to := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// Optional text is stored as NULL rather than an empty string
+func nullableString(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+// Optional references are stored as NULL rather than a zero id
+func nullableId(id int) any {
+	if id == 0 {
+		return nil
 	}
 
-	return p
+	return id
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// A store on a database of its own, which goes away with the test
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	return NewSQLiteStore()
+}
+
+// A new project in the store, for tasks to go in
+func addTestProject(t *testing.T, s *SQLiteStore, prefix string) Project {
+	t.Helper()
+
+	project, err := s.InsertProject(Project{name: prefix, prefix: prefix}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return project
+}
+
+// A new task in the project, as it's stored
+func addTestTask(t *testing.T, s *SQLiteStore, task Task) Task {
+	t.Helper()
+
+	created, err := s.InsertTask(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return created
+}
+
+func TestDaysBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2024-01-01", "2024-01-01", 0},
+		{"2024-01-01", "2024-01-08", 7},
+		{"2024-01-08", "2024-01-01", -7},
+		{"2024-02-28", "2024-03-01", 2},
+		// Clocks go forward in between in many places
+		{"2024-03-30", "2024-04-01", 2},
+	}
+
+	for _, tt := range tests {
+		a, _ := time.ParseInLocation(dateFormat, tt.a, time.Local)
+		b, _ := time.ParseInLocation(dateFormat, tt.b, time.Local)
+
+		if got := daysBetween(a, b); got != tt.want {
+			t.Errorf("daysBetween(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
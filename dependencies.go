@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// Returned by AddBlocker when the blocker already waits on the task, directly
+// or through other tasks, so neither could ever be done first
+var ErrCycle = errors.New("that would make the tasks block each other")
+
+// A task can be blocked by any number of others, in any project, and isn't
+// meant to move on until they're done.
+func (t *TaskDB) CreateDependencyTable() error {
+	_, err := t.db.Exec(`
+    CREATE TABLE IF NOT EXISTS task_dependencies (
+        task_id INTEGER NOT NULL,
+        blocker_id INTEGER NOT NULL,
+        PRIMARY KEY (task_id, blocker_id),
+        FOREIGN KEY (task_id) REFERENCES tasks (id),
+        FOREIGN KEY (blocker_id) REFERENCES tasks (id)
+    )
+    `)
+
+	return err
+}
+
+// Mark the task as blocked by another. Adding a blocker twice is fine, but
+// one that would make a cycle is refused with ErrCycle.
+func (t *TaskDB) AddBlocker(id int, blocker int) error {
+	if id == blocker {
+		return ErrCycle
+	}
+
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Follow what the blocker is blocked by, and what those are blocked
+	// by, and so on. If that leads back to the task, it's a cycle.
+	var cycle bool
+	err = tx.QueryRow(
+		`WITH RECURSIVE chain(id) AS (
+            SELECT ?
+            UNION
+            SELECT dep.blocker_id FROM task_dependencies AS dep JOIN chain ON dep.task_id = chain.id
+        )
+        SELECT EXISTS (SELECT 1 FROM chain WHERE id = ?)`,
+		blocker,
+		id,
+	).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+
+	if cycle {
+		return ErrCycle
+	}
+
+	// Both have to exist
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM tasks WHERE id IN (?, ?)", id, blocker).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count != 2 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec("INSERT OR IGNORE INTO task_dependencies (task_id, blocker_id) VALUES (?, ?)", id, blocker)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (t *TaskDB) RemoveBlocker(id int, blocker int) error {
+	_, err := t.db.Exec("DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ?", id, blocker)
+
+	return err
+}
+
+// The tasks blocking this one, done or not
+func (t *TaskDB) GetBlockers(id int) ([]Task, error) {
+	rows, err := t.db.Query(
+		taskSelect+`
+        JOIN task_dependencies AS dep ON dep.blocker_id = tasks.id
+        WHERE dep.task_id = ?
+        ORDER BY tasks.id`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTasks(rows)
+}
+
+// The tasks this one blocks
+func (t *TaskDB) GetBlocking(id int) ([]Task, error) {
+	rows, err := t.db.Query(
+		taskSelect+`
+        JOIN task_dependencies AS dep ON dep.task_id = tasks.id
+        WHERE dep.blocker_id = ?
+        ORDER BY tasks.id`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTasks(rows)
+}
+
+// Dependencies go when either of their tasks does
+const deleteDependencies = "DELETE FROM task_dependencies WHERE task_id = ? OR blocker_id = ?"
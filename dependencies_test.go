@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddBlockerCycle(t *testing.T) {
+	s := newTestStore(t)
+	project := addTestProject(t, s, "API")
+
+	a := addTestTask(t, s, NewTask(todo, "a", "", 0, project.id))
+	b := addTestTask(t, s, NewTask(todo, "b", "", 0, project.id))
+	c := addTestTask(t, s, NewTask(todo, "c", "", 0, project.id))
+
+	// a waits on b, which waits on c
+	if err := s.tasks.AddBlocker(a.Id, b.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.tasks.AddBlocker(b.Id, c.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding one twice is fine
+	if err := s.tasks.AddBlocker(a.Id, b.Id); err != nil {
+		t.Errorf("adding a blocker again: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		task, blocker int
+	}{
+		{"itself", a.Id, a.Id},
+		{"directly", b.Id, a.Id},
+		{"through another task", c.Id, a.Id},
+	}
+
+	for _, tt := range tests {
+		if err := s.tasks.AddBlocker(tt.task, tt.blocker); !errors.Is(err, ErrCycle) {
+			t.Errorf("blocking %s = %v, want ErrCycle", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+// Marks the start and end of the header of a task file
+const frontMatter = "---"
+
+// A task as it's written out for $EDITOR: a Markdown file with a header
+// for the title, labels (the task's tags) and due date, and the
+// description under it
+type TaskFile struct {
+	Title  string   `yaml:"title"`
+	Labels []string `yaml:"labels"`
+	Due    string   `yaml:"due"` // YYYY-MM-DD, empty if not due
+	Info   string   `yaml:"-"`
+}
+
+func taskFileOf(t Task) TaskFile {
+	return TaskFile{Title: t.Name, Labels: t.Tags, Due: jsonDate(t.Due), Info: t.Info}
+}
+
+func (f TaskFile) Format() ([]byte, error) {
+	if f.Labels == nil {
+		f.Labels = []string{}
+	}
+
+	header, err := yaml.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(frontMatter + "\n")
+	b.Write(header)
+	b.WriteString(frontMatter + "\n\n")
+	b.WriteString(f.Info)
+
+	return b.Bytes(), nil
+}
+
+// Read a task file back, checking the title and due date are still good
+func ParseTaskFile(data []byte) (TaskFile, error) {
+	var f TaskFile
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	rest, ok := strings.CutPrefix(text, frontMatter+"\n")
+	if !ok {
+		return f, errors.New("The file has to start with its --- header")
+	}
+
+	header, info, ok := strings.Cut(rest, "\n"+frontMatter+"\n")
+	if !ok {
+		// The header may be all there is, with no newline after it
+		header, ok = strings.CutSuffix(rest, "\n"+frontMatter)
+		if !ok {
+			return f, errors.New("The header has to end with a --- line")
+		}
+	}
+
+	if err := yaml.Unmarshal([]byte(header), &f); err != nil {
+		return f, fmt.Errorf("Couldn't read the header: %w", err)
+	}
+
+	f.Title = strings.TrimSpace(f.Title)
+	if f.Title == "" {
+		return f, errors.New("A task needs a title")
+	}
+
+	if _, err := parseDate(strings.TrimSpace(f.Due)); err != nil {
+		return f, fmt.Errorf("Due date must look like %s", dateFormat)
+	}
+
+	// Labels go through the same tidying as tags typed into the form
+	f.Labels = parseTags(formatTags(f.Labels))
+	if f.Labels == nil {
+		f.Labels = []string{}
+	}
+
+	f.Info = strings.TrimSpace(info)
+
+	return f, nil
+}
+
+func (f TaskFile) DueDate() time.Time {
+	due, _ := parseDate(strings.TrimSpace(f.Due))
+	return due
+}
+
+// Sent once the editor closes, with the file as it was saved
+type TaskFileMsg struct {
+	file TaskFile
+	err  error
+}
+
+// The editor to use, $VISUAL or $EDITOR, falling back to vi. It can have
+// arguments, e.g. "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// Write the file somewhere temporary and open it in the editor, with the
+// TUI put aside until it closes
+func editTaskFile(f TaskFile) tea.Cmd {
+	data, err := f.Format()
+	if err != nil {
+		return func() tea.Msg { return TaskFileMsg{err: err} }
+	}
+
+	tmp, err := os.CreateTemp("", "kanban-task-*.md")
+	if err != nil {
+		return func() tea.Msg { return TaskFileMsg{err: err} }
+	}
+
+	path := tmp.Name()
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return TaskFileMsg{err: err} }
+	}
+
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		defer os.Remove(path)
+
+		if err != nil {
+			return TaskFileMsg{err: fmt.Errorf("The editor didn't close cleanly: %w", err)}
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return TaskFileMsg{err: err}
+		}
+
+		f, err := ParseTaskFile(data)
+		return TaskFileMsg{file: f, err: err}
+	})
+}
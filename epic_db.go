@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// How many of each epic's children are in each lane. Archived ones don't
+// count.
+const childCounts = `SELECT parent_id, SUM(status = 0) AS todo, SUM(status = 1) AS in_progress,
+    SUM(status = 2) AS done
+    FROM tasks WHERE parent_id IS NOT NULL AND NOT archived GROUP BY parent_id`
+
+// Given for a parent on an epic, which can't be in anything
+var ErrEpicParent = errors.New("an epic can't be in another epic")
+
+// Children of an epic are let go when it's deleted, not deleted with it
+const releaseChildren = "UPDATE tasks SET parent_id = NULL WHERE parent_id = ?"
+
+// Tasks only go in epics, and epics don't go in anything, so they never
+// nest. ErrEpicParent if the task is an epic, sql.ErrNoRows if the parent
+// isn't one.
+func checkParent(tx *sql.Tx, id int, parent int, epic bool) error {
+	if epic {
+		return ErrEpicParent
+	}
+
+	if id == parent {
+		return sql.ErrNoRows
+	}
+
+	var ok bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ? AND epic)", parent).Scan(&ok)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Epics in open projects that aren't archived, by project and then key
+func (t *TaskDB) GetEpics() ([]Task, error) {
+	rows, err := t.db.Query(
+		taskSelect+`
+        WHERE tasks.epic AND NOT tasks.archived AND projects.status = ?
+        ORDER BY projects.sort_order, projects.id, tasks.seq`,
+		open,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTasks(rows)
+}
+
+// The tasks in the epic, from every project, archived ones aside
+func (t *TaskDB) GetChildren(id int) ([]Task, error) {
+	rows, err := t.db.Query(
+		taskSelect+`
+        WHERE tasks.parent_id = ? AND NOT tasks.archived
+        ORDER BY tasks.status, tasks.id`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTasks(rows)
+}
+
+func (t Task) ChildCount() int {
+	return t.Children[todo] + t.Children[inProgress] + t.Children[done]
+}
+
+// How much of an epic is done, from 0 to 1: its children in progress count
+// for half
+func (t Task) Progress() float64 {
+	if t.ChildCount() == 0 {
+		return 0
+	}
+
+	return (float64(t.Children[done]) + float64(t.Children[inProgress])/2) / float64(t.ChildCount())
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The bars are this wide, whatever the width of the window
+const epicBarWidth = 24
+
+// Every epic in the open projects, grouped by project, with how far along
+// its tasks are
+type Epics struct {
+	session  *Session
+	epics    []Task
+	projects map[int]Project
+	cursor   int
+	revision int   // Revision of the data the epics were loaded at
+	err      error // Why the epics couldn't be loaded, reported when they open
+	progress progress.Model
+	help     help.Model
+	keys     epicsKeyMap
+	width    int
+	height   int
+}
+
+func NewEpics(session *Session, width, height int) *Epics {
+	e := &Epics{
+		session: session,
+		progress: progress.New(
+			progress.WithScaledGradient(secondary, highlight),
+			progress.WithWidth(epicBarWidth),
+		),
+		help:   help.New(),
+		keys:   epicsKeys,
+		width:  width,
+		height: height,
+	}
+
+	e.err = e.load()
+
+	return e
+}
+
+func (e *Epics) load() error {
+	e.revision = currentRevision()
+
+	epics, err := store.GetEpics()
+	if err != nil {
+		return err
+	}
+
+	projectList, err := store.GetProjectsByStatus(open)
+	if err != nil {
+		return err
+	}
+
+	e.epics = epics
+	e.projects = make(map[int]Project)
+	for _, p := range projectList {
+		e.projects[p.id] = p
+	}
+
+	if e.cursor >= len(e.epics) {
+		e.cursor = max(len(e.epics)-1, 0)
+	}
+
+	return nil
+}
+
+func (e *Epics) selected() (Task, bool) {
+	if len(e.epics) == 0 {
+		return Task{}, false
+	}
+
+	return e.epics[e.cursor], true
+}
+
+func (e *Epics) Init() tea.Cmd {
+	if e.err != nil {
+		return reportError(e.err)
+	}
+
+	return nil
+}
+
+func (e *Epics) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.width = msg.Width
+		e.height = msg.Height
+	case RevisionMsg:
+		if msg.revision != e.revision {
+			if err := e.load(); err != nil {
+				return e, reportError(err)
+			}
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, e.keys.Quit):
+			return e, tea.Quit
+		case key.Matches(msg, e.keys.Up):
+			if e.cursor > 0 {
+				e.cursor--
+			}
+		case key.Matches(msg, e.keys.Down):
+			if e.cursor < len(e.epics)-1 {
+				e.cursor++
+			}
+		case key.Matches(msg, e.keys.Help):
+			e.help.ShowAll = !e.help.ShowAll
+		case key.Matches(msg, e.keys.View):
+			epic, ok := e.selected()
+			if !ok {
+				return e, nil
+			}
+
+			return e, Push(NewViewTask(e.session, e.width, e.height, epic))
+		case key.Matches(msg, e.keys.Board):
+			epic, ok := e.selected()
+			if !ok {
+				return e, nil
+			}
+
+			// The epic's own project, showing only the epic's tasks
+			b := NewBoard(e.session, epic.ProjectId, e.width, e.height)
+			b.epic = epic
+			b.filter.Epic = epic.Id
+			if err := b.initLists(e.width, e.height); err != nil && b.err == nil {
+				b.err = err
+			}
+			b.SelectTask(epic)
+
+			return e, Push(b)
+		case key.Matches(msg, e.keys.Projects):
+			return e, tea.Sequence(Pop(), e.RefreshProjects)
+		}
+	}
+
+	return e, nil
+}
+
+// How many of an epic's tasks are done, e.g. "3 of 8 done"
+func epicSummary(t Task) string {
+	if t.ChildCount() == 0 {
+		return "no tasks yet"
+	}
+
+	return fmt.Sprintf("%d of %d done", t.Children[done], t.ChildCount())
+}
+
+// The epic's tasks in each lane, e.g. "2 todo · 3 in progress · 3 done"
+func epicLanes(t Task, p Project) string {
+	var counts []string
+	for s, n := range t.Children {
+		counts = append(counts, fmt.Sprintf("%d %s", n, strings.ToLower(p.LaneTitle(status(s)))))
+	}
+
+	return strings.Join(counts, " · ")
+}
+
+func (e *Epics) View() string {
+	var lines []string
+	cursorLine := 0
+	lastProject := -1
+
+	for i, epic := range e.epics {
+		p := e.projects[epic.ProjectId]
+		if epic.ProjectId != lastProject {
+			header := agendaProjectStyle.Foreground(projectColor(p.color)).Render(p.name)
+			lines = append(lines, strings.Split(header, "\n")...)
+			lastProject = epic.ProjectId
+		}
+
+		style := agendaItemStyle
+		if i == e.cursor {
+			style = agendaSelectedStyle
+			cursorLine = len(lines)
+		}
+
+		item := fmt.Sprintf("%s %s", epic.Key(), epic.Name)
+		bar := fmt.Sprintf("%s  %s", e.progress.ViewAs(epic.Progress()), helpStyle.Render(epicSummary(epic)))
+		if epic.ChildCount() > 0 {
+			bar += helpStyle.Render(" · " + epicLanes(epic, p))
+		}
+
+		lines = append(lines, style.Render(item), agendaItemStyle.Render("  "+bar))
+	}
+
+	if len(e.epics) == 0 {
+		lines = append(lines, helpStyle.Render("No epics yet. Press E on a task to make it one."))
+	}
+
+	title := agendaTitleStyle.Render("Epics")
+	h := helpStyle.Render(e.help.View(e.keys))
+
+	// Only show as many lines as fit, keeping the cursor in view
+	available := e.height - lipgloss.Height(title) - lipgloss.Height(h) - 1
+	if available > 0 && len(lines) > available {
+		start := max(0, min(cursorLine-available/2, len(lines)-available))
+		lines = lines[start : start+available]
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
+
+	body := lipgloss.JoinVertical(lipgloss.Left, title, content)
+	body = lipgloss.PlaceVertical(max(available, 0)+lipgloss.Height(title), lipgloss.Top, body)
+
+	return lipgloss.JoinVertical(lipgloss.Left, body, h)
+}
+
+func (e *Epics) RefreshProjects() tea.Msg {
+	return RefreshProjectsMsg{}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// What estimates are counted in, see Config.EstimateUnit
+const (
+	pointsUnit = "points"
+	hoursUnit  = "hours"
+)
+
+// An estimate as it's typed in, e.g. "3" or "1.5". Empty means none.
+func parseEstimate(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	e, err := strconv.ParseFloat(s, 64)
+	if err != nil || e < 0 {
+		return 0, fmt.Errorf("Estimate must be a number of %s, like 3 or 1.5", config.estimateUnit())
+	}
+
+	return e, nil
+}
+
+// e.g. "3 pts" or "1.5h", depending on the unit
+func formatEstimate(e float64) string {
+	n := strconv.FormatFloat(e, 'f', -1, 64)
+	if config.estimateUnit() == hoursUnit {
+		return n + "h"
+	}
+
+	if e == 1 {
+		return n + " pt"
+	}
+
+	return n + " pts"
+}
+
+// The number as it goes in the form, empty if there's no estimate
+func estimateValue(e float64) string {
+	if e == 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(e, 'f', -1, 64)
+}
+
+// Optional estimates are stored as NULL rather than zero
+func nullableEstimate(e float64) any {
+	if e == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Sum of the estimates of the tasks, and whether any of them had one
+func totalEstimate(tasks []Task) (float64, bool) {
+	var total float64
+	var estimated bool
+	for _, t := range tasks {
+		total += t.Estimate
+		estimated = estimated || t.Estimate != 0
+	}
+
+	return total, estimated
+}
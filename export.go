@@ -0,0 +1,425 @@
+package main
+
+import (
+	"time"
+)
+
+// The JSON form of tasks and projects. This is what the API reads and
+// writes, so changing a field name breaks anything talking to it.
+
+type TaskJSON struct {
+	Id          int      `json:"id"`
+	Key         string   `json:"key"`
+	ProjectId   int      `json:"project_id"`
+	Project     string   `json:"project"` // Prefix of the project, e.g. "API"
+	Name        string   `json:"name"`
+	Info        string   `json:"info"`
+	Status      string   `json:"status"` // "todo", "in progress" or "done"
+	Due         string   `json:"due"`    // YYYY-MM-DD, empty if not due
+	Assignee    string   `json:"assignee"`
+	AssigneeId  int      `json:"assignee_id"`
+	CreatedBy   string   `json:"created_by"`
+	CreatedById int      `json:"created_by_id"`
+	Version     int      `json:"version"`
+	UpdatedAt   string   `json:"updated_at"` // RFC 3339, UTC
+	Priority    string   `json:"priority"`   // "none", "low", "medium" or "high"
+	Tags        []string `json:"tags"`
+	Archived    bool     `json:"archived"`
+	Recurrence  string   `json:"recurrence"` // See Recurrence, empty if it doesn't recur
+	Estimate    float64  `json:"estimate"`   // Points or hours, zero if not estimated
+	SprintId    int      `json:"sprint_id"`  // Zero for the backlog
+	Epic        bool     `json:"epic"`
+	ParentId    int      `json:"parent_id"` // The epic the task is part of, zero if none
+	// Key and name of the parent epic, read-only
+	Parent     string `json:"parent"`
+	ParentName string `json:"parent_name"`
+	// How many of an epic's tasks are in each lane, read-only
+	Children ChildCountsJSON `json:"children"`
+	// Blockers that aren't done, read-only
+	OpenBlockers int `json:"open_blockers"`
+	// RFC 3339, UTC, read-only. Completed is empty unless the task is done.
+	CreatedAt   string `json:"created_at"`
+	CompletedAt string `json:"completed_at"`
+}
+
+type ChildCountsJSON struct {
+	Todo       int `json:"todo"`
+	InProgress int `json:"in_progress"`
+	Done       int `json:"done"`
+}
+
+type ProjectJSON struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Prefix      string `json:"prefix"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Owner       string `json:"owner"`
+	TargetDate  string `json:"target_date"` // YYYY-MM-DD, empty if none
+	Archived    bool   `json:"archived"`
+	// One title per lane, empty for the usual ones
+	Lanes []string `json:"lanes"`
+}
+
+// A project to make, with its starter tasks. Only the name, info and tags
+// of the tasks are used, and they go in todo.
+type NewProjectJSON struct {
+	ProjectJSON
+	Tasks []TaskJSON `json:"tasks"`
+}
+
+type ProjectStatsJSON struct {
+	Todo         int    `json:"todo"`
+	InProgress   int    `json:"in_progress"`
+	Done         int    `json:"done"`
+	Overdue      int    `json:"overdue"`
+	LastActivity string `json:"last_activity"` // RFC 3339, empty if no tasks
+}
+
+type TimeEntryJSON struct {
+	Id        int    `json:"id"`
+	TaskId    int    `json:"task_id"`
+	Task      string `json:"task"` // Key of the task, e.g. "API-17"
+	TaskName  string `json:"task_name"`
+	UserId    int    `json:"user_id"`
+	User      string `json:"user"`
+	StartedAt string `json:"started_at"` // RFC 3339, UTC
+	StoppedAt string `json:"stopped_at"` // RFC 3339, empty while running
+	Seconds   int    `json:"seconds"`    // So far, if it's running
+}
+
+type TimeTotalJSON struct {
+	ProjectId int    `json:"project_id"`
+	Project   string `json:"project"` // Name of the project
+	TaskId    int    `json:"task_id"`
+	Task      string `json:"task"` // Key of the task
+	TaskName  string `json:"task_name"`
+	Seconds   int    `json:"seconds"`
+}
+
+type SprintJSON struct {
+	Id              int     `json:"id"`
+	ProjectId       int     `json:"project_id"`
+	Name            string  `json:"name"`
+	Start           string  `json:"start"`     // YYYY-MM-DD
+	End             string  `json:"end"`       // YYYY-MM-DD, the last day
+	ClosedAt        string  `json:"closed_at"` // RFC 3339, empty while open
+	Planned         int     `json:"planned"`
+	Completed       int     `json:"completed"`
+	PlannedPoints   float64 `json:"planned_points"`
+	CompletedPoints float64 `json:"completed_points"`
+}
+
+type UserJSON struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+func taskToJSON(t Task) TaskJSON {
+	var updated string
+	if !t.UpdatedAt.IsZero() {
+		updated = t.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return TaskJSON{
+		Id:          t.Id,
+		Key:         t.Key(),
+		ProjectId:   t.ProjectId,
+		Project:     t.Prefix,
+		Name:        t.Name,
+		Info:        t.Info,
+		Status:      t.Status.String(),
+		Due:         jsonDate(t.Due),
+		Assignee:    t.AssigneeName,
+		AssigneeId:  t.Assignee,
+		CreatedBy:   t.CreatedByName,
+		CreatedById: t.CreatedBy,
+		Version:     t.Version,
+		UpdatedAt:   updated,
+		Priority:    t.Priority.String(),
+		Tags:        t.Tags,
+		Archived:    t.Archived,
+		Recurrence:  t.Recurrence,
+		Estimate:    t.Estimate,
+		SprintId:    t.SprintId,
+		Epic:        t.Epic,
+		ParentId:    t.ParentId,
+		Parent:      t.ParentKey,
+		ParentName:  t.ParentName,
+		Children: ChildCountsJSON{
+			Todo:       t.Children[todo],
+			InProgress: t.Children[inProgress],
+			Done:       t.Children[done],
+		},
+
+		OpenBlockers: t.OpenBlockers,
+		CreatedAt:    jsonTime(t.CreatedAt),
+		CompletedAt:  jsonTime(t.CompletedAt),
+	}
+}
+
+func taskFromJSON(j TaskJSON) (Task, error) {
+	task := Task{
+		Id:            j.Id,
+		Name:          j.Name,
+		Info:          j.Info,
+		ProjectId:     j.ProjectId,
+		Prefix:        j.Project,
+		Assignee:      j.AssigneeId,
+		AssigneeName:  j.Assignee,
+		CreatedBy:     j.CreatedById,
+		CreatedByName: j.CreatedBy,
+		Version:       j.Version,
+		Tags:          j.Tags,
+		Archived:      j.Archived,
+		Recurrence:    j.Recurrence,
+		Estimate:      j.Estimate,
+		SprintId:      j.SprintId,
+		Epic:          j.Epic,
+		ParentId:      j.ParentId,
+		ParentKey:     j.Parent,
+		ParentName:    j.ParentName,
+		OpenBlockers:  j.OpenBlockers,
+	}
+
+	task.Children[todo] = j.Children.Todo
+	task.Children[inProgress] = j.Children.InProgress
+	task.Children[done] = j.Children.Done
+
+	if j.Key != "" {
+		_, seq, err := ParseTaskKey(j.Key)
+		if err != nil {
+			return task, err
+		}
+
+		task.Seq = seq
+	}
+
+	st, err := GetStatusFromString(j.Status)
+	if err != nil {
+		return task, err
+	}
+
+	task.Status = status(st)
+
+	if j.Priority != "" {
+		if task.Priority, err = GetPriorityFromString(j.Priority); err != nil {
+			return task, err
+		}
+	}
+
+	if task.Due, err = parseDate(j.Due); err != nil {
+		return task, err
+	}
+
+	if task.CreatedAt, err = parseJSONTime(j.CreatedAt); err != nil {
+		return task, err
+	}
+
+	if task.CompletedAt, err = parseJSONTime(j.CompletedAt); err != nil {
+		return task, err
+	}
+
+	task.UpdatedAt, err = parseJSONTime(j.UpdatedAt)
+
+	return task, err
+}
+
+func projectToJSON(p Project) ProjectJSON {
+	return ProjectJSON{
+		Id:          p.id,
+		Name:        p.name,
+		Prefix:      p.prefix,
+		Description: p.description,
+		Color:       p.color,
+		Owner:       p.owner,
+		TargetDate:  jsonDate(p.targetDate),
+		Archived:    p.status == archived,
+		Lanes:       p.lanes,
+	}
+}
+
+func projectFromJSON(j ProjectJSON) (Project, error) {
+	project := Project{
+		id:          j.Id,
+		name:        j.Name,
+		prefix:      j.Prefix,
+		description: j.Description,
+		color:       j.Color,
+		owner:       j.Owner,
+	}
+
+	var err error
+	if project.lanes, err = parseLanes(formatLanes(j.Lanes)); err != nil {
+		return project, err
+	}
+
+	if j.Archived {
+		project.status = archived
+	}
+
+	project.targetDate, err = parseDate(j.TargetDate)
+
+	return project, err
+}
+
+func statsToJSON(s ProjectStats) ProjectStatsJSON {
+	var last string
+	if !s.lastActivity.IsZero() {
+		last = s.lastActivity.UTC().Format(time.RFC3339)
+	}
+
+	return ProjectStatsJSON{
+		Todo:         s.counts[todo],
+		InProgress:   s.counts[inProgress],
+		Done:         s.counts[done],
+		Overdue:      s.overdue,
+		LastActivity: last,
+	}
+}
+
+func statsFromJSON(j ProjectStatsJSON) (ProjectStats, error) {
+	stats := ProjectStats{overdue: j.Overdue}
+	stats.counts[todo] = j.Todo
+	stats.counts[inProgress] = j.InProgress
+	stats.counts[done] = j.Done
+
+	var err error
+	stats.lastActivity, err = parseJSONTime(j.LastActivity)
+
+	return stats, err
+}
+
+func timeEntryToJSON(e TimeEntry) TimeEntryJSON {
+	return TimeEntryJSON{
+		Id:        e.Id,
+		TaskId:    e.TaskId,
+		Task:      e.TaskKey,
+		TaskName:  e.TaskName,
+		UserId:    e.UserId,
+		User:      e.UserName,
+		StartedAt: jsonTime(e.Start),
+		StoppedAt: jsonTime(e.Stop),
+		Seconds:   int(e.Duration() / time.Second),
+	}
+}
+
+func timeEntryFromJSON(j TimeEntryJSON) (TimeEntry, error) {
+	entry := TimeEntry{
+		Id:       j.Id,
+		TaskId:   j.TaskId,
+		TaskKey:  j.Task,
+		TaskName: j.TaskName,
+		UserId:   j.UserId,
+		UserName: j.User,
+	}
+
+	var err error
+	if entry.Start, err = parseJSONTime(j.StartedAt); err != nil {
+		return entry, err
+	}
+
+	entry.Stop, err = parseJSONTime(j.StoppedAt)
+
+	return entry, err
+}
+
+func timeTotalToJSON(t TimeTotal) TimeTotalJSON {
+	return TimeTotalJSON{
+		ProjectId: t.ProjectId,
+		Project:   t.ProjectName,
+		TaskId:    t.TaskId,
+		Task:      t.TaskKey,
+		TaskName:  t.TaskName,
+		Seconds:   t.Seconds,
+	}
+}
+
+func timeTotalFromJSON(j TimeTotalJSON) TimeTotal {
+	return TimeTotal{
+		ProjectId:   j.ProjectId,
+		ProjectName: j.Project,
+		TaskId:      j.TaskId,
+		TaskKey:     j.Task,
+		TaskName:    j.TaskName,
+		Seconds:     j.Seconds,
+	}
+}
+
+func sprintToJSON(s Sprint) SprintJSON {
+	return SprintJSON{
+		Id:              s.Id,
+		ProjectId:       s.ProjectId,
+		Name:            s.Name,
+		Start:           jsonDate(s.Start),
+		End:             jsonDate(s.End),
+		ClosedAt:        jsonTime(s.Closed),
+		Planned:         s.Planned,
+		Completed:       s.Completed,
+		PlannedPoints:   s.PlannedPoints,
+		CompletedPoints: s.CompletedPoints,
+	}
+}
+
+func sprintFromJSON(j SprintJSON) (Sprint, error) {
+	sprint := Sprint{
+		Id:              j.Id,
+		ProjectId:       j.ProjectId,
+		Name:            j.Name,
+		Planned:         j.Planned,
+		Completed:       j.Completed,
+		PlannedPoints:   j.PlannedPoints,
+		CompletedPoints: j.CompletedPoints,
+	}
+
+	var err error
+	if sprint.Start, err = parseDate(j.Start); err != nil {
+		return sprint, err
+	}
+
+	if sprint.End, err = parseDate(j.End); err != nil {
+		return sprint, err
+	}
+
+	sprint.Closed, err = parseJSONTime(j.ClosedAt)
+
+	return sprint, err
+}
+
+func userToJSON(u User) UserJSON {
+	return UserJSON{Id: u.id, Name: u.name, DisplayName: u.displayName}
+}
+
+func userFromJSON(j UserJSON) User {
+	return User{id: j.Id, name: j.Name, displayName: j.DisplayName}
+}
+
+// Times are RFC 3339 in JSON, and empty when they're not set
+func parseJSONTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+
+	return t.Local(), err
+}
+
+// The other way round, UTC
+func jsonTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Dates are empty strings in JSON when they're not set
+func jsonDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(dateFormat)
+}
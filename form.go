@@ -1,178 +1,611 @@
 package main
 
 import (
-	"log"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Styles
-var titleStyle = lipgloss.NewStyle().
-	Padding(1).
-	BorderStyle(lipgloss.RoundedBorder()).
-	BorderForeground(secondaryColor).
-	Align(lipgloss.Center)
+// Fields of the task form, in tab order
+const (
+	titleField = iota
+	descField
+	laneField
+	priorityField
+	dueField
+	tagsField
+	assigneeField
+	recurrenceField
+	estimateField
+	numTaskFields
+)
+
+var fieldLabels = [numTaskFields]string{
+	"Title", "Description", "Lane", "Priority", "Due date", "Labels", "Assignee", "Repeats", "Estimate",
+}
 
-var descStyle = titleStyle
+// The form is this wide, or narrower if the window is, whether it's for a
+// new task or an existing one
+const (
+	maxFormWidth = 72
+	minFormWidth = 40
+	labelWidth   = 13
+	descHeight   = 6
+)
 
-// Form Model
+// Styles
+var (
+	labelStyle = lipgloss.NewStyle().
+			Width(labelWidth).
+			Foreground(grey)
+	focusedLabelStyle = labelStyle.Copy().
+				Foreground(highlightColor).
+				Bold(true)
+	choiceStyle = lipgloss.NewStyle().
+			Foreground(highlightColor)
+	fieldErrorStyle = errorStyle.Copy().
+			PaddingLeft(labelWidth)
+)
+
+// Form for a new task, or for editing one. Tab and shift+tab go from field
+// to field, and the lane and priority are picked with the arrow keys.
 type Form struct {
-	focused     status
+	session     *Session
+	lane        status // The lane the task is in, or goes in
+	from        status // The lane it was in when the form was opened
 	editing     bool
 	index       int // Index within current list
+	focus       int
 	title       textinput.Model
 	description textarea.Model
-	project     int
-	id          int // DB id of task
+	priority    priority
+	tags        textinput.Model
+	due         textinput.Model
+	assignee    textinput.Model
+	recurrence  textinput.Model
+	estimate    textinput.Model
+	errs        [numTaskFields]string // What's wrong with each field, if anything
+	err         string
+	loadErr     error // Why the project couldn't be read, reported when the form opens
+	notice      string
+	project     Project
+	sprint      int    // Sprint a new task is planned into, if any
+	id          int    // DB id of task
+	key         string // Key of the task being edited
+	version     int    // Version of the task when the form was opened
 	keys        formKeyMap
 	help        help.Model
 	width       int
 	height      int
 }
 
-func NewTitle() textinput.Model {
+func newFormInput(placeholder string) textinput.Model {
 	ti := textinput.New()
-	ti.Placeholder = "What is the task's title?"
+	ti.Prompt = ""
+	ti.Placeholder = placeholder
 	return ti
 }
 
-func TitleView(title textinput.Model) string {
-	return titleStyle.Render(title.View())
-}
+func newForm(session *Session, project int, width, height int) *Form {
+	form := &Form{session: session, keys: formKeys, help: help.New()}
 
-func DescView(title textarea.Model) string {
-	return descStyle.Render(title.View())
-}
+	// There's no key to toggle it while typing, so all the keys are shown
+	form.help.ShowAll = true
 
-func NewDescription() textarea.Model {
-	ta := textarea.New()
-	ta.Placeholder = "Brief description"
-	return ta
-}
+	// The editor would run on the server, for anyone connected to it
+	form.keys.Editor.SetEnabled(!session.ssh)
 
-func NewForm(width, height int, focused status, project int) *Form {
-	form := &Form{width: width, height: height, focused: focused, keys: formKeys, help: help.New()}
-	form.title = NewTitle()
-	form.description = NewDescription()
-	form.editing = false
-	form.project = project
+	details, err := store.GetProject(project)
+	if err != nil {
+		form.loadErr = err
+		details.id = project
+	}
+	form.project = details
+
+	form.title = newFormInput("What is the task's title?")
+	form.description = textarea.New()
+	form.description.Placeholder = "Brief description, in Markdown"
+	form.description.ShowLineNumbers = false
+	form.tags = newFormInput("comma separated (optional)")
+	form.due = newFormInput(dateFormat + " (optional)")
+	form.assignee = newFormInput("user name, or \"me\" (optional)")
+	form.recurrence = newFormInput("daily, weekly mon,thu, monthly 15 or cron (optional)")
+	if config.estimateUnit() == hoursUnit {
+		form.estimate = newFormInput("hours, e.g. 1.5 (optional)")
+	} else {
+		form.estimate = newFormInput("story points, e.g. 3 (optional)")
+	}
 
+	form.resize(width, height)
 	form.title.Focus()
+
+	return form
+}
+
+func NewForm(session *Session, width, height int, lane status, project int) *Form {
+	form := newForm(session, project, width, height)
+	form.lane = lane
+	form.from = lane
+
 	return form
 }
 
-func UpdateForm(task Task, index int) *Form {
-	form := &Form{focused: task.Status, keys: formKeys, help: help.New()}
-	form.title = NewTitle()
-	form.description = NewDescription()
+func UpdateForm(session *Session, task Task, index int, width, height int) *Form {
+	form := newForm(session, task.ProjectId, width, height)
 	form.editing = true
 	form.index = index
+	form.id = task.Id
+	form.key = task.Key()
+	form.version = task.Version
+	form.lane = task.Status
+	form.from = task.Status
+	form.priority = task.Priority
 
 	form.title.SetValue(task.Name)
 	form.description.SetValue(task.Info)
-	form.id = task.Id
-	form.project = task.ProjectId
+	form.tags.SetValue(strings.Join(task.Tags, ", "))
+	if !task.Due.IsZero() {
+		form.due.SetValue(task.Due.Format(dateFormat))
+	}
+	form.assignee.SetValue(task.AssigneeName)
+	form.recurrence.SetValue(task.Recurrence)
+	form.estimate.SetValue(estimateValue(task.Estimate))
 
-	form.title.Focus()
 	return form
 }
 
-func (m Form) Init() tea.Cmd {
+// Size the fields to the window
+func (m *Form) resize(width, height int) {
+	m.width = width
+	m.height = height
+
+	formWidth := min(max(width-8, minFormWidth), maxFormWidth)
+	for _, input := range m.inputs() {
+		input.Width = formWidth - labelWidth - 1
+	}
+
+	m.description.SetWidth(formWidth)
+	m.description.SetHeight(descHeight)
+	m.help.Width = width
+}
+
+// The single line fields, by field
+func (m *Form) inputs() map[int]*textinput.Model {
+	return map[int]*textinput.Model{
+		titleField:      &m.title,
+		dueField:        &m.due,
+		tagsField:       &m.tags,
+		assigneeField:   &m.assignee,
+		recurrenceField: &m.recurrence,
+		estimateField:   &m.estimate,
+	}
+}
+
+// Move focus to the given field
+func (m *Form) focusField(field int) tea.Cmd {
+	if input, ok := m.inputs()[m.focus]; ok {
+		input.Blur()
+	}
+	m.description.Blur()
+
+	m.focus = (field + numTaskFields) % numTaskFields
+
+	if m.focus == descField {
+		m.description.Focus()
+		return textarea.Blink
+	}
+
+	if input, ok := m.inputs()[m.focus]; ok {
+		input.Focus()
+		return textinput.Blink
+	}
+
 	return nil
 }
 
+func (m Form) Init() tea.Cmd {
+	if m.loadErr != nil {
+		return tea.Batch(textinput.Blink, reportError(m.loadErr))
+	}
+
+	return textinput.Blink
+}
+
+// Sent to the form once the task is saved, with the message for the board
+type taskSavedMsg struct {
+	msg tea.Msg
+}
+
 func (m Form) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width, msg.Height)
+		return m, nil
+	case TaskTemplateMsg:
+		m.useTemplate(msg.template)
+		return m, nil
+	case taskSavedMsg:
+		return m, tea.Sequence(Pop(), func() tea.Msg {
+			return msg.msg
+		})
+	case TaskFileMsg:
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+
+		m.useFile(msg.file)
+		return m, nil
 	case tea.KeyMsg:
+		m.notice = ""
+
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
+		case key.Matches(msg, m.keys.Back):
+			return m, Pop()
 		case key.Matches(msg, m.keys.Next):
-			if m.title.Focused() {
-				m.title.Blur()
-				m.description.Focus()
-				return m, textarea.Blink
+			return m, m.focusField(m.focus + 1)
+		case key.Matches(msg, m.keys.Prev):
+			return m, m.focusField(m.focus - 1)
+		case key.Matches(msg, m.keys.Save):
+			return m.save()
+		case key.Matches(msg, m.keys.Change) && (m.focus == laneField || m.focus == priorityField):
+			delta := 1
+			if msg.String() == "left" {
+				delta = -1
+			}
+
+			if m.focus == laneField {
+				m.lane = status((int(m.lane) + delta + numStatus) % numStatus)
 			} else {
-				// Insert new task into db
-				models[form] = m
+				m.priority = priority((int(m.priority) + delta + len(priority_strings)) % len(priority_strings))
+			}
+
+			return m, nil
+		case key.Matches(msg, m.keys.Editor):
+			return m, editTaskFile(m.file())
+		case key.Matches(msg, m.keys.Template):
+			templates, err := allTemplates()
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
 
-				if m.editing {
-					return models[board], m.UpdateTask
+			picker := NewPicker("Use which template?", "template", taskTemplateItems(templates), m.width, m.height, func(item list.Item) tea.Cmd {
+				return func() tea.Msg {
+					return TaskTemplateMsg{template: item.(taskTemplateItem).template}
 				}
+			})
 
-				return models[board], m.CreateTask
+			return m, Push(picker)
+		case key.Matches(msg, m.keys.SaveTemplate):
+			template := m.template()
+			if template.Name == "" {
+				m.err = "Give the task a title to save it as a template"
+				return m, nil
 			}
-		case key.Matches(msg, m.keys.Back):
-			if m.description.Focused() {
-				m.description.Blur()
-				m.title.Focus()
-				return m, textinput.Blink
-			} else {
-				return models[board], nil
+
+			if err := store.SaveTaskTemplate(template); err != nil {
+				return m, reportError(err)
 			}
+
+			m.err = ""
+			m.notice = "Saved as the template " + template.Name
+			return m, nil
 		}
+
+		// Typing in a field clears what was wrong with it
+		m.errs[m.focus] = ""
 	}
 
-	// Pass all other key presses to the inputs
-	if m.title.Focused() {
-		m.title, cmd = m.title.Update(msg)
-		return m, cmd
-	} else {
+	// Pass all other messages to the focused field
+	if m.focus == descField {
 		m.description, cmd = m.description.Update(msg)
 		return m, cmd
 	}
+
+	if input, ok := m.inputs()[m.focus]; ok {
+		*input, cmd = input.Update(msg)
+	}
+
+	return m, cmd
+}
+
+// Check every field, and save the task if they're all good. Otherwise the
+// cursor goes to the first field that isn't.
+func (m Form) save() (tea.Model, tea.Cmd) {
+	m.errs = m.validate()
+	m.err = ""
+
+	for field, err := range m.errs {
+		if err != "" {
+			m.err = "Fix the fields marked above to save the task"
+			return m, m.focusField(field)
+		}
+	}
+
+	write := m.CreateTask
+	if m.editing {
+		write = m.UpdateTask
+	}
+
+	// Save while the form is still open, so it stays open with everything
+	// in it if the save fails, and hand the result to the board once it's
+	// back
+	return m, func() tea.Msg {
+		msg := write()
+		if _, failed := msg.(ErrorMsg); failed {
+			return msg
+		}
+
+		return taskSavedMsg{msg: msg}
+	}
+}
+
+// What's wrong with each field, empty for the ones that are fine
+func (m Form) validate() [numTaskFields]string {
+	var errs [numTaskFields]string
+
+	if strings.TrimSpace(m.title.Value()) == "" {
+		errs[titleField] = "A task needs a title"
+	}
+
+	if _, err := parseDate(strings.TrimSpace(m.due.Value())); err != nil {
+		errs[dueField] = "Due date must look like " + dateFormat
+	}
+
+	if strings.Contains(m.tags.Value(), "#") {
+		errs[tagsField] = "Labels are separated by commas, without a #"
+	}
+
+	if _, err := m.assigneeId(); err != nil {
+		errs[assigneeField] = err.Error()
+	}
+
+	if _, err := m.parseRecurrence(); err != nil {
+		errs[recurrenceField] = err.Error()
+	}
+
+	if _, err := parseEstimate(m.estimate.Value()); err != nil {
+		errs[estimateField] = err.Error()
+	}
+
+	return errs
+}
+
+// A label and its field on one line, with what's wrong with it under it
+func (m Form) fieldView(field int, value string) string {
+	label := labelStyle.Render(fieldLabels[field] + ":")
+	if m.focus == field {
+		label = focusedLabelStyle.Render(fieldLabels[field] + ":")
+	}
+
+	view := lipgloss.JoinHorizontal(lipgloss.Top, label, value)
+	if m.errs[field] != "" {
+		view += "\n" + fieldErrorStyle.Render(m.errs[field])
+	}
+
+	return view
+}
+
+// One of a few values, picked with the arrow keys while it's focused
+func (m Form) choiceView(field int, value string) string {
+	if m.focus == field {
+		return m.fieldView(field, choiceStyle.Render("‹ "+value+" ›"))
+	}
+
+	return m.fieldView(field, value)
 }
 
 func (m Form) View() string {
+	title := "New task in " + m.project.name
+	if m.editing {
+		title = "Edit " + m.key
+	}
+
+	desc := m.fieldView(descField, "")
+	desc += "\n" + m.description.View()
+
+	views := []string{
+		nameStyle.Copy().MarginBottom(1).Render(title),
+		m.fieldView(titleField, m.title.View()),
+		desc,
+		m.choiceView(laneField, m.project.LaneTitle(m.lane)),
+		m.choiceView(priorityField, m.priority.String()),
+		m.fieldView(dueField, m.due.View()),
+		m.fieldView(tagsField, m.tags.View()),
+		m.fieldView(assigneeField, m.assignee.View()),
+		m.fieldView(recurrenceField, m.recurrence.View()),
+		m.fieldView(estimateField, m.estimate.View()),
+	}
+
+	if m.err != "" {
+		views = append(views, "", errorStyle.Render(m.err))
+	}
+
+	if m.notice != "" {
+		views = append(views, "", helpStyle.Render(m.notice))
+	}
+
 	render := lipgloss.JoinVertical(
 		lipgloss.Center,
-		TitleView(m.title),
-		DescView(m.description),
-		m.help.View(formKeys),
+		newProjectStyle.Render(lipgloss.JoinVertical(lipgloss.Left, views...)),
+		m.help.View(m.keys),
 	)
 
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, render)
 }
 
 func (m Form) CreateTask() tea.Msg {
-	task := NewTask(m.focused, m.title.Value(), m.description.Value(), 0, m.project)
-
-	// Insert task into db
-	taskDB := GetDB()
-	defer taskDB.db.Close()
-	newTask, err := taskDB.Insert(task.Name, task.Info, task.ProjectId, task.Status)
+	task := NewTask(m.lane, strings.TrimSpace(m.title.Value()), m.description.Value(), 0, m.project.id)
+	task.Priority = m.priority
+	task.Due, _ = parseDate(strings.TrimSpace(m.due.Value()))
+	task.Tags = parseTags(m.tags.Value())
+	assignee, err := m.assigneeId()
 	if err != nil {
-		log.Fatal(err)
+		return ErrorMsg{err: err}
 	}
 
-	// Assign the new ID to the new task, so it can be actioned
-	// in the board without taking a large poopoo.
-	var newId int64
-	newId, err = newTask.LastInsertId()
+	task.Assignee = assignee
+	task.Recurrence, _ = m.parseRecurrence()
+	task.Estimate, _ = parseEstimate(m.estimate.Value())
+	task.SprintId = m.sprint
+	task.CreatedBy = m.session.user.id
+
+	// Insert task into db. What comes back has the new ID and key, so
+	// it can be actioned in the board without taking a large poopoo.
+	task, err = store.InsertTask(task)
 	if err != nil {
-		log.Fatal(err)
+		return ErrorMsg{err: err}
 	}
 
-	task.Id = int(newId)
-
 	// Return create task message
 	return CreateTaskMsg{task: task}
 }
 
 func (m Form) UpdateTask() tea.Msg {
-	task := NewTask(m.focused, m.title.Value(), m.description.Value(), m.id, m.project)
+	task := NewTask(m.lane, strings.TrimSpace(m.title.Value()), m.description.Value(), m.id, m.project.id)
+	task.Priority = m.priority
+	task.Due, _ = parseDate(strings.TrimSpace(m.due.Value()))
+	task.Tags = parseTags(m.tags.Value())
+	assignee, err := m.assigneeId()
+	if err != nil {
+		return ErrorMsg{err: err}
+	}
+
+	task.Assignee = assignee
+	task.Recurrence, _ = m.parseRecurrence()
+	task.Estimate, _ = parseEstimate(m.estimate.Value())
+	task.Version = m.version
+
+	task, err = saveTask(task)
+	if errors.Is(err, ErrConflict) {
+		return ConflictMsg{task: task}
+	}
+
+	if err != nil {
+		return ErrorMsg{err: err}
+	}
+
+	return EditTaskMsg{task: task, index: m.index, from: m.from}
+}
+
+// Write every field of the task in one go, so emptied ones are cleared, and
+// read it back so it keeps its key. A zero version overwrites whatever is
+// there. If someone else changed the task first, nothing is written and our
+// version comes back, under its existing key, with ErrConflict.
+func saveTask(task Task) (Task, error) {
+	err := store.UpdateTask(task)
+	if err != nil && !errors.Is(err, ErrConflict) {
+		return task, err
+	}
+
+	curr, getErr := store.GetTask(task.Id)
+	if getErr != nil {
+		return task, getErr
+	}
+
+	if err != nil {
+		task.Prefix = curr.Prefix
+		task.Seq = curr.Seq
+		return task, err
+	}
+
+	return curr, nil
+}
+
+// Sent by the template picker with the template to fill the form in from
+type TaskTemplateMsg struct {
+	template TaskTemplate
+}
+
+// Fill in the title, description and tags from the template. An empty
+// title in the template keeps the one typed in.
+func (m *Form) useTemplate(t TaskTemplate) {
+	if t.Title != "" {
+		m.title.SetValue(t.Title)
+	}
+
+	m.description.SetValue(t.Info())
+	m.tags.SetValue(strings.Join(t.Tags, ", "))
+	m.err = ""
+}
+
+// What's in the form, to be written out for the editor
+func (m Form) file() TaskFile {
+	return TaskFile{
+		Title:  m.title.Value(),
+		Labels: parseTags(m.tags.Value()),
+		Due:    strings.TrimSpace(m.due.Value()),
+		Info:   m.description.Value(),
+	}
+}
+
+// Fill the form in from the file the editor saved
+func (m *Form) useFile(f TaskFile) {
+	m.title.SetValue(f.Title)
+	m.description.SetValue(f.Info)
+	m.tags.SetValue(strings.Join(f.Labels, ", "))
+	m.due.SetValue(strings.TrimSpace(f.Due))
+	m.errs = [numTaskFields]string{}
+	m.err = ""
+}
+
+// What's in the form as a task template, named after the title
+func (m Form) template() TaskTemplate {
+	title := strings.TrimSpace(m.title.Value())
+
+	return TaskTemplate{
+		Name:        title,
+		Title:       title,
+		Description: m.description.Value(),
+		Tags:        parseTags(m.tags.Value()),
+	}
+}
+
+// The rule in the recurrence field as it's stored, empty if there isn't one
+func (m Form) parseRecurrence() (string, error) {
+	value := strings.TrimSpace(m.recurrence.Value())
+	if value == "" {
+		return "", nil
+	}
 
-	// Update task in db
-	taskDB := GetDB()
-	defer taskDB.db.Close()
-	taskDB.Update(task)
+	r, err := ParseRecurrence(value)
+	if err != nil {
+		return "", err
+	}
+
+	return r.String(), nil
+}
+
+// Look up the user named in the assignee field, zero for nobody. Naming
+// someone who isn't a user is an error, rather than a way to add them.
+func (m Form) assigneeId() (int, error) {
+	name := strings.TrimSpace(m.assignee.Value())
+	if name == "" {
+		return 0, nil
+	}
+
+	if name == "me" {
+		return m.session.user.id, nil
+	}
+
+	user, err := store.FindUser(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("Unknown user %s", name)
+	}
 
-	return EditTaskMsg{task: task, index: m.index}
+	return user.id, err
 }
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+// What the rows of a grouped board are split by
+type grouping int
+
+const (
+	groupNone grouping = iota
+	groupAssignee
+	groupTag
+	groupPriority
+	groupEpic
+	numGroupings
+)
+
+var grouping_strings = [...]string{"none", "assignee", "tag", "priority", "epic"}
+
+func (g grouping) String() string {
+	return grouping_strings[g]
+}
+
+// Styles
+var (
+	groupHeaderStyle = lipgloss.NewStyle().
+				Foreground(grey)
+	groupFocusHeaderStyle = lipgloss.NewStyle().
+				Foreground(highlightColor).
+				Bold(true)
+	groupFocusTitleStyle = listTitleStyle.Copy().
+				Bold(true).
+				Underline(true)
+	groupFocusTaskStyle = lipgloss.NewStyle().
+				Foreground(highlightColor).
+				Bold(true)
+)
+
+// A row of a grouped board, with its tasks in each lane
+type taskGroup struct {
+	key   string // Empty for the tasks with nothing to group them by
+	title string
+	rank  int // Groups are ordered by rank, then title
+	tasks [numStatus][]Task
+}
+
+func (g taskGroup) count() int {
+	var n int
+	for _, tasks := range g.tasks {
+		n += len(tasks)
+	}
+
+	return n
+}
+
+// The groups a task goes in, as a group with no tasks yet. A task with
+// several tags goes in the group of each of them.
+func groupsOf(t Task, by grouping) []taskGroup {
+	switch by {
+	case groupAssignee:
+		if t.Assignee == 0 {
+			return []taskGroup{{title: "Unassigned", rank: 1}}
+		}
+
+		return []taskGroup{{key: fmt.Sprint(t.Assignee), title: t.AssigneeName}}
+	case groupTag:
+		if len(t.Tags) == 0 {
+			return []taskGroup{{title: "No tag", rank: 1}}
+		}
+
+		var groups []taskGroup
+		for _, tag := range t.Tags {
+			groups = append(groups, taskGroup{key: tag, title: "#" + tag})
+		}
+
+		return groups
+	case groupPriority:
+		// Most urgent first
+		if t.Priority == noPriority {
+			return []taskGroup{{title: "No priority", rank: len(priority_strings)}}
+		}
+
+		title := strings.ToUpper(t.Priority.String()[:1]) + t.Priority.String()[1:]
+		return []taskGroup{{key: t.Priority.String(), title: title, rank: len(priority_strings) - int(t.Priority)}}
+	case groupEpic:
+		// An epic heads its own row, with its tasks in this project
+		switch {
+		case t.Epic:
+			return []taskGroup{{key: fmt.Sprint(t.Id), title: t.Key() + " " + t.Name}}
+		case t.ParentId != 0:
+			return []taskGroup{{key: fmt.Sprint(t.ParentId), title: t.ParentKey + " " + t.ParentName}}
+		}
+
+		return []taskGroup{{title: "No epic", rank: 1}}
+	}
+
+	return []taskGroup{{title: "All tasks"}}
+}
+
+// Split the tasks in the lanes into rows, keeping the order they're in
+// within each lane
+func groupTasks(lanes []SwimLane, by grouping) []taskGroup {
+	var groups []taskGroup
+	index := make(map[string]int)
+
+	for _, lane := range lanes {
+		for _, t := range lane.Tasks() {
+			for _, g := range groupsOf(t, by) {
+				i, ok := index[g.key]
+				if !ok {
+					i = len(groups)
+					index[g.key] = i
+					groups = append(groups, g)
+				}
+
+				groups[i].tasks[lane.laneStatus] = append(groups[i].tasks[lane.laneStatus], t)
+			}
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].rank != groups[j].rank {
+			return groups[i].rank < groups[j].rank
+		}
+
+		return strings.ToLower(groups[i].title) < strings.ToLower(groups[j].title)
+	})
+
+	return groups
+}
+
+// A place the cursor can be in a lane of a grouped board: on a task, or
+// on a row with no task to show in that lane
+type groupSlot struct {
+	group string
+	task  int // Zero if there's no task
+}
+
+func (m Board) groups() []taskGroup {
+	return groupTasks(m.lanes, m.grouping)
+}
+
+// Where the cursor is: the row it's in, and the task under it if any. The
+// lane's selected task is only under the cursor while it's showing in the
+// cursor's row.
+func (m Board) groupCursor(groups []taskGroup) (int, int) {
+	var row int
+	for i, g := range groups {
+		if g.key == m.group {
+			row = i
+		}
+	}
+
+	selected := m.lanes[m.focused].list.SelectedItem()
+	if len(groups) == 0 || selected == nil || m.collapsed[groups[row].key] {
+		return row, 0
+	}
+
+	for _, t := range groups[row].tasks[m.focused] {
+		if t.Id == selected.(Task).Id {
+			return row, t.Id
+		}
+	}
+
+	return row, 0
+}
+
+// Every place the cursor can go in the focused lane, top to bottom
+func (m Board) groupSlots(groups []taskGroup) []groupSlot {
+	var slots []groupSlot
+	for _, g := range groups {
+		tasks := g.tasks[m.focused]
+		if m.collapsed[g.key] || len(tasks) == 0 {
+			slots = append(slots, groupSlot{group: g.key})
+			continue
+		}
+
+		for _, t := range tasks {
+			slots = append(slots, groupSlot{group: g.key, task: t.Id})
+		}
+	}
+
+	return slots
+}
+
+func (m *Board) selectSlot(slot groupSlot) {
+	m.group = slot.group
+	if slot.task == 0 {
+		return
+	}
+
+	for i, item := range m.lanes[m.focused].list.Items() {
+		if item.(Task).Id == slot.task {
+			m.lanes[m.focused].list.Select(i)
+			break
+		}
+	}
+}
+
+// Put the cursor on the first task in its row in the focused lane, if
+// there is one
+func (m *Board) selectInGroup() {
+	groups := m.groups()
+	row, _ := m.groupCursor(groups)
+	if len(groups) == 0 || m.collapsed[groups[row].key] {
+		return
+	}
+
+	if tasks := groups[row].tasks[m.focused]; len(tasks) > 0 {
+		m.selectSlot(groupSlot{group: groups[row].key, task: tasks[0].Id})
+	}
+}
+
+// Move the cursor of a grouped board for the arrow keys, which go from
+// task to task down the rows. False for any other key.
+func (m *Board) moveInGroups(msg tea.KeyMsg) bool {
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		m.moveGroupCursor(-1)
+	case key.Matches(msg, m.keys.Down):
+		m.moveGroupCursor(1)
+	case key.Matches(msg, m.keys.Left):
+		m.Prev()
+		m.selectInGroup()
+	case key.Matches(msg, m.keys.Right):
+		m.Next()
+		m.selectInGroup()
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Move the cursor up (negative) or down the focused lane, across rows
+func (m *Board) moveGroupCursor(delta int) {
+	groups := m.groups()
+	slots := m.groupSlots(groups)
+	if len(slots) == 0 {
+		return
+	}
+
+	row, task := m.groupCursor(groups)
+
+	var i int
+	for j, slot := range slots {
+		if slot.group == groups[row].key && (slot.task == task || task == 0) {
+			i = j
+			break
+		}
+	}
+
+	m.selectSlot(slots[min(max(i+delta, 0), len(slots)-1)])
+}
+
+// The rows of a grouped board, one under the other, each with its tasks
+// in every lane and a count at the top of each cell. Rows that are
+// folded only show the counts. Scrolls to keep the cursor in view.
+func (m Board) groupedView(height int) string {
+	width := m.width/numStatus - horizontalPad
+	groups := m.groups()
+	row, selected := m.groupCursor(groups)
+
+	cell := lipgloss.NewStyle().Width(width).MaxWidth(width)
+
+	var titles []string
+	for s := range numStatus {
+		style := listTitleStyle
+		if status(s) == m.focused {
+			style = groupFocusTitleStyle
+		}
+
+		titles = append(titles, cell.Render(style.Render(m.details.LaneTitle(status(s)))))
+	}
+
+	lines := []string{lipgloss.JoinHorizontal(lipgloss.Top, titles...), ""}
+	cursor := 0
+
+	if len(groups) == 0 {
+		lines = append(lines, helpStyle.Render("No tasks"))
+	}
+
+	for i, g := range groups {
+		arrow := "▾"
+		if m.collapsed[g.key] {
+			arrow = "▸"
+		}
+
+		header := fmt.Sprintf("%s %s · %s", arrow, g.title, taskCount(g.count()))
+		if i == row {
+			cursor = len(lines)
+			lines = append(lines, groupFocusHeaderStyle.Render(header))
+		} else {
+			lines = append(lines, groupHeaderStyle.Render(header))
+		}
+
+		var cells []string
+		for s, tasks := range g.tasks {
+			count := taskCount(len(tasks))
+			if total, estimated := totalEstimate(tasks); estimated {
+				count += " · " + formatEstimate(total)
+			}
+
+			cellLines := []string{helpStyle.Render(count)}
+			if !m.collapsed[g.key] {
+				for _, t := range tasks {
+					title := truncate.StringWithTail(laneTask{Task: t, marked: m.marked[t.Id]}.Title(), uint(max(width-3, 1)), "…")
+					if i == row && status(s) == m.focused && t.Id == selected {
+						cursor = len(lines) + len(cellLines)
+						cellLines = append(cellLines, groupFocusTaskStyle.Render("│ "+title))
+					} else {
+						cellLines = append(cellLines, "  "+title)
+					}
+				}
+			}
+
+			cells = append(cells, cell.Render(strings.Join(cellLines, "\n")))
+		}
+
+		lines = append(lines, strings.Split(lipgloss.JoinHorizontal(lipgloss.Top, cells...), "\n")...)
+		lines = append(lines, "")
+	}
+
+	// The lane titles stay put, and the rows scroll under them. However
+	// small the terminal, that takes room for at least one row.
+	height = max(height, 3)
+	if len(lines) > height {
+		top := min(max(cursor-height/2, 2), len(lines)-height+2)
+		lines = append(lines[:2], lines[top:top+height-2]...)
+	}
+
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return lipgloss.NewStyle().Padding(0, horizontalPad).Render(strings.Join(lines, "\n"))
+}
@@ -5,38 +5,131 @@ import (
 )
 
 type boardKeyMap struct {
-	Up       key.Binding
-	Down     key.Binding
-	Left     key.Binding
-	Right    key.Binding
-	Help     key.Binding
-	Edit     key.Binding
-	New      key.Binding
-	Move     key.Binding
-	View     key.Binding
-	Delete   key.Binding
-	Projects key.Binding
-	Quit     key.Binding
+	Up         key.Binding
+	Down       key.Binding
+	Left       key.Binding
+	Right      key.Binding
+	Help       key.Binding
+	Edit       key.Binding
+	New        key.Binding
+	Move       key.Binding
+	View       key.Binding
+	Delete     key.Binding
+	Assign     key.Binding
+	OnlyMine   key.Binding
+	Timer      key.Binding
+	Progress   key.Binding
+	Burndown   key.Binding
+	SprintOnly key.Binding
+	Sprints    key.Binding
+	EpicOnly   key.Binding
+	Group      key.Binding
+	Fold       key.Binding
+	Mark       key.Binding
+	Unmark     key.Binding
+	Bulk       key.Binding
+	MoveTo     key.Binding
+	CopyTo     key.Binding
+	Projects   key.Binding
+	Quit       key.Binding
 }
 
 type formKeyMap struct {
-	Next key.Binding
-	Back key.Binding
-	Quit key.Binding
+	Next         key.Binding
+	Prev         key.Binding
+	Change       key.Binding
+	Save         key.Binding
+	Back         key.Binding
+	Editor       key.Binding
+	Template     key.Binding
+	SaveTemplate key.Binding
+	Quit         key.Binding
 }
 
 type projectListKeyMap struct {
 	Up           key.Binding
 	Down         key.Binding
+	MoveUp       key.Binding
+	MoveDown     key.Binding
+	Sort         key.Binding
+	Reverse      key.Binding
 	New          key.Binding
+	Details      key.Binding
+	Edit         key.Binding
+	Rename       key.Binding
 	Archive      key.Binding
+	Unarchive    key.Binding
+	Delete       key.Binding
 	ViewArchived key.Binding
+	Agenda       key.Binding
+	Epics        key.Binding
+	SaveTemplate key.Binding
 	Quit         key.Binding
 	Help         key.Binding
 	Select       key.Binding
 }
 
+type agendaKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Move     key.Binding
+	View     key.Binding
+	Board    key.Binding
+	Projects key.Binding
+	Help     key.Binding
+	Quit     key.Binding
+}
+
+type epicsKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	View     key.Binding
+	Board    key.Binding
+	Projects key.Binding
+	Help     key.Binding
+	Quit     key.Binding
+}
+
 type viewTaskKeyMap struct {
+	Up            key.Binding
+	Down          key.Binding
+	ScrollUp      key.Binding
+	ScrollDown    key.Binding
+	Open          key.Binding
+	AddBlocker    key.Binding
+	RemoveBlocker key.Binding
+	ToggleEpic    key.Binding
+	SetEpic       key.Binding
+	Editor        key.Binding
+	Back          key.Binding
+	Quit          key.Binding
+}
+
+type burndownKeyMap struct {
+	Earlier  key.Binding
+	Later    key.Binding
+	Wider    key.Binding
+	Narrower key.Binding
+	Burnup   key.Binding
+	Points   key.Binding
+	Help     key.Binding
+	Back     key.Binding
+	Quit     key.Binding
+}
+
+type sprintsKeyMap struct {
+	Up    key.Binding
+	Down  key.Binding
+	New   key.Binding
+	Close key.Binding
+	Help  key.Binding
+	Back  key.Binding
+	Quit  key.Binding
+}
+
+type viewProjectKeyMap struct {
+	Open key.Binding
+	Edit key.Binding
 	Back key.Binding
 	Quit key.Binding
 }
@@ -51,19 +144,24 @@ func (k boardKeyMap) ShortHelp() []key.Binding {
 // key.Map interface.
 func (k boardKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Left, k.Right},   // first column
-		{k.New, k.Edit, k.View, k.Delete}, // second column
-		{k.Projects, k.Quit, k.Help},      // third column
+		{k.Up, k.Down, k.Left, k.Right},                           // first column
+		{k.New, k.Edit, k.View, k.Delete},                         // second column
+		{k.MoveTo, k.CopyTo, k.Progress, k.Burndown, k.Sprints},   // third column
+		{k.Assign, k.OnlyMine, k.SprintOnly, k.EpicOnly, k.Timer}, // fourth column
+		{k.Mark, k.Unmark, k.Bulk, k.Group, k.Fold},               // fifth column
+		{k.Projects, k.Quit, k.Help},                              // sixth column
 	}
 }
 
 func (k formKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Next, k.Back, k.Quit}
+	return []key.Binding{k.Next, k.Change, k.Save, k.Back}
 }
 
 func (k formKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Next, k.Back, k.Quit},
+		{k.Next, k.Prev, k.Change},
+		{k.Save, k.Back, k.Quit},
+		{k.Editor, k.Template, k.SaveTemplate},
 	}
 }
 
@@ -73,19 +171,82 @@ func (k projectListKeyMap) ShortHelp() []key.Binding {
 
 func (k projectListKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Select},
-		{k.New, k.Archive, k.ViewArchived},
+		{k.Up, k.Down, k.Select, k.Details, k.Agenda, k.Epics},
+		{k.MoveUp, k.MoveDown, k.Sort, k.Reverse, k.ViewArchived},
+		{k.New, k.Edit, k.Rename, k.SaveTemplate},
+		{k.Archive, k.Unarchive, k.Delete},
 		{k.Help, k.Quit},
 	}
 }
 
+func (k epicsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.View, k.Board, k.Projects, k.Help}
+}
+
+func (k epicsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},
+		{k.View, k.Board},
+		{k.Projects, k.Help, k.Quit},
+	}
+}
+
+func (k agendaKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Move, k.View, k.Board, k.Help}
+}
+
+func (k agendaKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},
+		{k.Move, k.View, k.Board},
+		{k.Projects, k.Help, k.Quit},
+	}
+}
+
 func (k viewTaskKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Back, k.Quit}
+	return []key.Binding{k.Open, k.AddBlocker, k.RemoveBlocker, k.ToggleEpic, k.SetEpic, k.Editor, k.Back, k.Quit}
 }
 
 func (k viewTaskKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Back, k.Quit}, // first column
+		{k.Up, k.Down, k.Open, k.ScrollUp, k.ScrollDown}, // first column
+		{k.AddBlocker, k.RemoveBlocker},                  // second column
+		{k.ToggleEpic, k.SetEpic},                        // third column
+		{k.Back, k.Quit},                                 // fourth column
+	}
+}
+
+func (k burndownKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Earlier, k.Later, k.Burnup, k.Back, k.Help}
+}
+
+func (k burndownKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Earlier, k.Later, k.Wider, k.Narrower},
+		{k.Burnup, k.Points},
+		{k.Back, k.Help, k.Quit},
+	}
+}
+
+func (k sprintsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.New, k.Close, k.Back, k.Help}
+}
+
+func (k sprintsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},
+		{k.New, k.Close},
+		{k.Back, k.Help, k.Quit},
+	}
+}
+
+func (k viewProjectKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Open, k.Edit, k.Back, k.Quit}
+}
+
+func (k viewProjectKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Open, k.Edit, k.Back, k.Quit},
 	}
 }
 
@@ -130,9 +291,69 @@ var boardKeys = boardKeyMap{
 		key.WithKeys("d"),
 		key.WithHelp("d", "delete task"),
 	),
+	Assign: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "assign to me"),
+	),
+	OnlyMine: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "only my tasks"),
+	),
+	Timer: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "start/stop timer"),
+	),
+	Progress: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "count tasks/points"),
+	),
+	Burndown: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "burndown chart"),
+	),
+	SprintOnly: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "current sprint only"),
+	),
+	Sprints: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "sprints"),
+	),
+	EpicOnly: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "only this epic"),
+	),
+	Group: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "group rows by"),
+	),
+	Fold: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "fold/unfold row"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark task"),
+	),
+	Unmark: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "unmark all"),
+	),
+	Bulk: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "change marked tasks"),
+	),
+	MoveTo: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "move to project"),
+	),
+	CopyTo: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy to project"),
+	),
 	Projects: key.NewBinding(
 		key.WithKeys("p"),
-		key.WithHelp("p", "projects"),
+		key.WithHelp("p", "back"),
 	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "esc", "ctrl+c"),
@@ -142,13 +363,37 @@ var boardKeys = boardKeyMap{
 
 var formKeys = formKeyMap{
 	Next: key.NewBinding(
-		key.WithKeys("ctrl+y"),
-		key.WithHelp("ctrl+y", "next field/confirm"),
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next field"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("shift+tab"),
+		key.WithHelp("shift+tab", "previous field"),
+	),
+	Change: key.NewBinding(
+		key.WithKeys("left", "right"),
+		key.WithHelp("←/→", "change lane/priority"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "save"),
 	),
 	Back: key.NewBinding(
 		key.WithKeys("esc", "ctrl+b"),
 		key.WithHelp("esc, ctrl+b", "back"),
 	),
+	Editor: key.NewBinding(
+		key.WithKeys("ctrl+e"),
+		key.WithHelp("ctrl+e", "open in $EDITOR"),
+	),
+	Template: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "use template"),
+	),
+	SaveTemplate: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "save as template"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c"),
 		key.WithHelp("ctrl+c", "quit"),
@@ -164,6 +409,22 @@ var projectListKeys = projectListKeyMap{
 		key.WithKeys("down", "j"),
 		key.WithHelp("↓/j", "move down"),
 	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("shift+up", "K"),
+		key.WithHelp("shift+↑/K", "move project up"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("shift+down", "J"),
+		key.WithHelp("shift+↓/J", "move project down"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "change sort"),
+	),
+	Reverse: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "reverse sort"),
+	),
 	Help: key.NewBinding(
 		key.WithKeys("?"),
 		key.WithHelp("?", "toggle help"),
@@ -172,14 +433,46 @@ var projectListKeys = projectListKeyMap{
 		key.WithKeys("n"),
 		key.WithHelp("n", "new project"),
 	),
+	Details: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "project details"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit project"),
+	),
+	Rename: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rename project"),
+	),
 	Archive: key.NewBinding(
 		key.WithKeys("a"),
 		key.WithHelp("a", "archive project"),
 	),
+	Unarchive: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "unarchive project"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "delete project"),
+	),
 	ViewArchived: key.NewBinding(
 		key.WithKeys("v"),
 		key.WithHelp("v", "view archived projects"),
 	),
+	Agenda: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "my day"),
+	),
+	Epics: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "epics"),
+	),
+	SaveTemplate: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "save as template"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("q, ctrl+c", "quit"),
@@ -190,7 +483,202 @@ var projectListKeys = projectListKeyMap{
 	),
 }
 
+var agendaKeys = agendaKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Move: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "move task"),
+	),
+	View: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "view task"),
+	),
+	Board: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "go to board"),
+	),
+	Projects: key.NewBinding(
+		key.WithKeys("p", "esc"),
+		key.WithHelp("p", "back"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+var epicsKeys = epicsKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	View: key.NewBinding(
+		key.WithKeys("enter", "v"),
+		key.WithHelp("enter", "view epic"),
+	),
+	Board: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "board of the epic"),
+	),
+	Projects: key.NewBinding(
+		key.WithKeys("p", "esc"),
+		key.WithHelp("p", "back"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
 var viewTaskKeys = viewTaskKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "previous linked task"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "next linked task"),
+	),
+	ScrollUp: key.NewBinding(
+		key.WithKeys("pgup", "ctrl+u"),
+		key.WithHelp("pgup", "scroll description up"),
+	),
+	ScrollDown: key.NewBinding(
+		key.WithKeys("pgdown", "ctrl+d"),
+		key.WithHelp("pgdn", "scroll description down"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open linked task"),
+	),
+	AddBlocker: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "add blocker"),
+	),
+	RemoveBlocker: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "remove blocker"),
+	),
+	ToggleEpic: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "make/unmake epic"),
+	),
+	SetEpic: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "put in epic"),
+	),
+	Editor: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit in $EDITOR"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("b", "esc"),
+		key.WithHelp("b, esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q, ctrl+c", "quit"),
+	),
+}
+
+var burndownKeys = burndownKeyMap{
+	Earlier: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/h", "week earlier"),
+	),
+	Later: key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("→/l", "week later"),
+	),
+	Wider: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "add a week"),
+	),
+	Narrower: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "drop a week"),
+	),
+	Burnup: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "burndown/burnup"),
+	),
+	Points: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "count tasks/points"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("b", "esc"),
+		key.WithHelp("b, esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q, ctrl+c", "quit"),
+	),
+}
+
+var sprintsKeys = sprintsKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	New: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new sprint"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "close sprint"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("b", "esc"),
+		key.WithHelp("b, esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q, ctrl+c", "quit"),
+	),
+}
+
+var viewProjectKeys = viewProjectKeyMap{
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open board"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit project"),
+	),
 	Back: key.NewBinding(
 		key.WithKeys("b", "esc"),
 		key.WithHelp("b, esc", "back"),
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -8,17 +10,10 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// Models
-var models []tea.Model
-
-const (
-	board status = iota
-	form
-	projects
-	viewTask
-)
-
 func main() {
+	remote := flag.String("remote", "", "URL of a kanban server to use instead of the local database")
+	flag.Parse()
+
 	f, err := tea.LogToFile("debug.log", "debug")
 	if err != nil {
 		fmt.Println("fatal:", err)
@@ -26,16 +21,59 @@ func main() {
 	}
 	defer f.Close()
 
-	// NewBoard is defined in board.go
-	// NewForm is defined in form.go
-	// NewProjects is defined in projects.go
-	// NewViewTask is defined in view_task.go
 	log.Println("Starting Cli...")
 
-	// TODO confirm that the new form project here doesn't matter?
-	models = []tea.Model{NewBoard(0, 0, 0), NewForm(0, 0, todo, 0), NewProjectsTable(), NewViewTask(0, 0, Task{Name: "hi"})}
-	m := models[projects]
-	p := tea.NewProgram(m)
+	config, err = loadConfig()
+	if err != nil {
+		fmt.Println("fatal:", err)
+		os.Exit(1)
+	}
+
+	if *remote == "" {
+		*remote = config.Remote
+	}
+
+	if *remote != "" {
+		store = NewRemoteStore(*remote, config.APIToken)
+	} else {
+		store = NewSQLiteStore()
+	}
+
+	// Make sure whoever is running this has a user, so tasks can be
+	// attributed to them.
+	user, err := store.EnsureUser(config.UserName(), config.DisplayName)
+	if err != nil {
+		fmt.Println("fatal:", err)
+		os.Exit(1)
+	}
+
+	// Subcommands, e.g. `kanban-cli serve`. Without one, run the board.
+	if flag.NArg() > 0 {
+		switch flag.Arg(0) {
+		case "serve":
+			err = serve(flag.Args()[1:], user)
+		case "ssh-serve":
+			err = sshServe(flag.Args()[1:])
+		case "recur":
+			err = recur(flag.Args()[1:])
+		case "time":
+			err = timeReport(flag.Args()[1:], user)
+		default:
+			err = fmt.Errorf("unknown command %q", flag.Arg(0))
+		}
+
+		if err != nil {
+			fmt.Println("fatal:", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	p := tea.NewProgram(NewSession(user).Start())
+
+	// Pick up changes made by other people and terminals
+	go watchRevision(context.Background(), p)
 
 	if _, err := p.Run(); err != nil {
 		fmt.Println(err)
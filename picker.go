@@ -0,0 +1,124 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var pickerStyle = lipgloss.NewStyle().
+	Padding(1, 2)
+
+// Projects in the picker, which filters on the prefix as well as the name
+type projectItem struct {
+	project Project
+}
+
+func (i projectItem) Title() string {
+	return i.project.prefix + "  " + i.project.name
+}
+
+func (i projectItem) Description() string {
+	return i.project.description
+}
+
+func (i projectItem) FilterValue() string {
+	return i.project.prefix + " " + i.project.name
+}
+
+// A list to pick one thing from, type to filter. Picking one goes back to
+// the screen underneath and runs the command pick makes for it.
+type Picker struct {
+	list list.Model
+	pick func(list.Item) tea.Cmd
+	err  error // Why the items couldn't be loaded, reported when it opens
+}
+
+// name is what the items are called, e.g. "project"
+func NewPicker(title, name string, items []list.Item, width, height int, pick func(list.Item) tea.Cmd) *Picker {
+	d := list.NewDefaultDelegate()
+	d.Styles.SelectedTitle = listFocusItemStyle
+	d.Styles.SelectedDesc = listFocusItemDescStyle
+
+	l := list.New(items, d, 0, 0)
+	l.Title = title
+	l.Styles.Title = listTitleStyle
+	l.SetStatusBarItemName(name, name+"s")
+
+	// Esc goes back instead
+	l.KeyMap.Quit.SetEnabled(false)
+
+	p := &Picker{list: l, pick: pick}
+	p.setSize(width, height)
+
+	return p
+}
+
+// Open projects other than the one with the id exclude
+func NewProjectPicker(title string, width, height int, exclude int, pick func(Project) tea.Cmd) *Picker {
+	projects, err := store.GetProjectsByStatus(open)
+
+	var items []list.Item
+	for _, p := range projects {
+		if p.id != exclude {
+			items = append(items, projectItem{project: p})
+		}
+	}
+
+	picker := NewPicker(title, "project", items, width, height, func(item list.Item) tea.Cmd {
+		return pick(item.(projectItem).project)
+	})
+	picker.err = err
+
+	return picker
+}
+
+func (p *Picker) setSize(width, height int) {
+	h, v := pickerStyle.GetFrameSize()
+	p.list.SetSize(width-h, height-v)
+}
+
+func (p *Picker) Init() tea.Cmd {
+	if p.err != nil {
+		return reportError(p.err)
+	}
+
+	return nil
+}
+
+func (p *Picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.setSize(msg.Width, msg.Height)
+		return p, nil
+	case tea.KeyMsg:
+		// While filtering, keys are for the filter
+		if p.list.FilterState() == list.Filtering {
+			break
+		}
+
+		switch {
+		case msg.String() == "esc" && p.list.FilterState() == list.Unfiltered:
+			return p, Pop()
+		case key.Matches(msg, p.list.KeyMap.ForceQuit):
+			return p, tea.Quit
+		case msg.String() == "enter":
+			item := p.list.SelectedItem()
+			if item == nil {
+				return p, nil
+			}
+
+			return p, tea.Sequence(Pop(), p.pick(item))
+		}
+	}
+
+	var cmd tea.Cmd
+	p.list, cmd = p.list.Update(msg)
+
+	return p, cmd
+}
+
+func (p *Picker) View() string {
+	return pickerStyle.Render(p.list.View())
+}
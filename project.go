@@ -1,9 +1,12 @@
 package main
 
 import (
-	"log"
+	"fmt"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -11,11 +14,18 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/gobeam/stringy"
 )
 
 var tableStyle = lipgloss.NewStyle().
 	Margin(2, 1)
 
+// The progress column takes up the spare width, within these bounds
+const (
+	minProgressWidth = 10
+	maxProgressWidth = 30
+)
+
 var newProjectStyle = lipgloss.NewStyle().
 	Padding(1, 2).
 	Border(lipgloss.RoundedBorder(), true).
@@ -24,22 +34,130 @@ var newProjectStyle = lipgloss.NewStyle().
 var centerCtyle = lipgloss.NewStyle().
 	Align(lipgloss.Center)
 
+var errorStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("9"))
+
+var tableHeaderStyle = table.DefaultStyles().Header.
+	BorderStyle(lipgloss.NormalBorder()).
+	BorderForeground(grey).
+	BorderRight(true).
+	BorderBottom(true)
+
+var tableCellStyle = table.DefaultStyles().Cell.
+	BorderStyle(lipgloss.NormalBorder()).
+	BorderForeground(grey).
+	BorderRight(true)
+
 type Project struct {
-	id        int
-	name      string
-	order     int
-	status    projectStatus
-	todoTasks []Task
-	ipTasks   []Task
-	doneTasks []Task
+	id          int
+	name        string
+	order       int
+	status      projectStatus
+	prefix      string
+	description string
+	color       string
+	owner       string
+	targetDate  time.Time // Zero if the project has no target date
+	lanes       []string  // Titles of the lanes, one per status, or nil for the usual ones
+}
+
+// The title of the lane for the status, e.g. "In Progress" unless the
+// project calls it something else
+func (p Project) LaneTitle(s status) string {
+	if len(p.lanes) == numStatus {
+		return p.lanes[s]
+	}
+
+	return stringy.New(s.String()).Title()
+}
+
+// Lane titles as they're typed in and stored, e.g. "Backlog, Doing, Shipped".
+// Empty means the usual titles.
+func parseLanes(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	lanes := strings.Split(s, ",")
+	for i := range lanes {
+		lanes[i] = strings.TrimSpace(lanes[i])
+		if lanes[i] == "" {
+			return nil, fmt.Errorf("Lane titles can't be empty")
+		}
+	}
+
+	if len(lanes) != numStatus {
+		return nil, fmt.Errorf("Give a title for each of the %d lanes, separated by commas", numStatus)
+	}
+
+	return lanes, nil
+}
+
+func formatLanes(lanes []string) string {
+	return strings.Join(lanes, ", ")
+}
+
+// A project along with the task stats shown in the projects table
+type projectSummary struct {
+	Project
+	ProjectStats
+}
+
+func (p projectSummary) total() int {
+	var total int
+	for _, c := range p.counts {
+		total += c
+	}
+
+	return total
+}
+
+// Fraction of tasks that are done, or -1 for a project without tasks
+func (p projectSummary) progress() float64 {
+	if p.total() == 0 {
+		return -1
+	}
+
+	return float64(p.counts[done]) / float64(p.total())
+}
+
+type projectSort int
+
+const (
+	sortManual projectSort = iota
+	sortName
+	sortProgress
+	sortActivity
+	numProjectSorts
+)
+
+var projectSortStrings = [...]string{"manual order", "name", "progress", "last activity"}
+
+func (s projectSort) String() string {
+	return projectSortStrings[s]
 }
 
 type ProjectsTable struct {
-	projects []Project
+	session  *Session
+	projects []projectSummary
 	table    table.Model
 	keys     projectListKeyMap
 	help     help.Model
 	view     projectStatus
+	sortBy   projectSort
+	reverse  bool
+	revision int   // Revision of the data the table was loaded at
+	err      error // Why the projects couldn't be loaded, reported when it opens
+
+	// Inline rename of the selected project
+	renaming bool
+	rename   textinput.Model
+
+	// Asks before archiving or deleting a project
+	confirm Confirm
+
+	// Shown in place of the help until the next key
+	notice string
 
 	// Store these if this is the first view, and pass to subsequent models
 	width  int
@@ -47,6 +165,10 @@ type ProjectsTable struct {
 }
 
 func (p *ProjectsTable) Init() tea.Cmd {
+	if p.err != nil {
+		return reportError(p.err)
+	}
+
 	return nil
 }
 
@@ -56,14 +178,55 @@ func (p *ProjectsTable) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		p.height = msg.Height
 		p.width = msg.Width
 		p.setViewSize(msg.Height)
+		p.layout()
 	case RefreshProjectsMsg:
-		columns, rows := buildTable(p.view)
-		p.table.SetColumns(columns)
-		p.table.SetRows(rows)
+		if err := p.refresh(); err != nil {
+			return p, reportError(err)
+		}
+	case RevisionMsg:
+		if msg.revision != p.revision {
+			if err := p.refresh(); err != nil {
+				return p, reportError(err)
+			}
+		}
 	case tea.KeyMsg:
+		if p.renaming {
+			return p.updateRename(msg)
+		}
+
+		if p.confirm.Open() {
+			var cmd tea.Cmd
+			p.confirm, cmd = p.confirm.Update(msg)
+			return p, cmd
+		}
+
+		p.notice = ""
+
 		switch {
 		case key.Matches(msg, p.keys.Quit):
 			return p, tea.Quit
+		case key.Matches(msg, p.keys.SaveTemplate):
+			pId, ok := p.selectedProject()
+			if !ok {
+				return p, nil
+			}
+
+			// Read it fresh, for the lanes
+			project, err := store.GetProject(pId)
+			if err != nil {
+				return p, reportError(err)
+			}
+
+			template, err := projectTemplateFrom(project)
+			if err != nil {
+				return p, reportError(err)
+			}
+
+			if err := store.SaveProjectTemplate(template); err != nil {
+				return p, reportError(err)
+			}
+
+			p.notice = fmt.Sprintf("Saved %s as a template with %s", template.Name, taskCount(len(template.Tasks)))
 		case key.Matches(msg, p.keys.Up):
 			if !p.table.Focused() {
 				p.table.Focus()
@@ -80,41 +243,57 @@ func (p *ProjectsTable) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Get a new kanban board for this project
 			pId, err := strconv.Atoi(row[0])
 			if err != nil {
-				log.Fatal(err)
-				return nil, nil
+				return p, reportError(err)
 			}
 
-			b := NewBoard(pId, p.width, p.height)
-			models[projects] = p
-			models[board] = b
-			return models[board], nil
+			return p, Push(NewBoard(p.session, pId, p.width, p.height))
 		case key.Matches(msg, p.keys.Help):
 			p.help.ShowAll = !p.help.ShowAll
 			p.help.Update(nil)
 			p.setViewSize(p.height)
 		case key.Matches(msg, p.keys.New):
-			f := NewProjectForm(p.width, p.height)
-			return f, nil
+			return p, Push(NewProjectForm(p.session, p.width, p.height))
+		case key.Matches(msg, p.keys.Agenda):
+			return p, Push(NewAgenda(p.session, p.width, p.height))
+		case key.Matches(msg, p.keys.Epics):
+			return p, Push(NewEpics(p.session, p.width, p.height))
+		case key.Matches(msg, p.keys.Edit), key.Matches(msg, p.keys.Details):
+			if len(p.projects) == 0 {
+				return p, nil
+			}
+
+			// Read it fresh, the table may be out of date
+			project, err := store.GetProject(p.projects[p.table.Cursor()].id)
+			if err != nil {
+				return p, reportError(err)
+			}
+
+			if key.Matches(msg, p.keys.Edit) {
+				return p, Push(EditProjectForm(p.session, project, p.width, p.height))
+			}
+
+			return p, Push(NewViewProject(p.session, p.width, p.height, project))
 		case key.Matches(msg, p.keys.Archive):
-			i := p.table.Cursor()
-			if i >= 0 {
-				projectDB := GetProjectDB()
-				defer projectDB.db.Close()
+			pId, ok := p.selectedProject()
+			if !ok {
+				return p, nil
+			}
 
-				row := p.table.SelectedRow()
-				pId, err := strconv.Atoi(row[0])
+			project := p.projects[p.table.Cursor()]
+			prompt := fmt.Sprintf("Archive %s?", project.name)
+
+			var cmd tea.Cmd
+			p.confirm, cmd = Ask(prompt, func() tea.Msg {
+				err := store.ArchiveProject(pId)
 				if err != nil {
-					log.Fatal(err)
+					return ErrorMsg{err: err}
 				}
 
-				projectDB.ArchiveProject(pId)
+				// Takes the row out of this view
+				return RefreshProjectsMsg{}
+			})
 
-				// Remove row from this view
-				rows := p.table.Rows()
-				newRows := append(rows[:i], rows[i+1:]...)
-				p.table.SetRows(newRows)
-				p.table.SetCursor(i - 1)
-			}
+			return p, cmd
 		case key.Matches(msg, p.keys.ViewArchived):
 			if p.view == open {
 				p.view = archived
@@ -122,15 +301,170 @@ func (p *ProjectsTable) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				p.view = open
 			}
 
-			columns, rows := buildTable(p.view)
-			p.table.SetColumns(columns)
-			p.table.SetRows(rows)
+			if err := p.refresh(); err != nil {
+				return p, reportError(err)
+			}
+		case key.Matches(msg, p.keys.Unarchive):
+			pId, ok := p.selectedProject()
+			if !ok || p.view != archived {
+				return p, nil
+			}
+
+			err := store.UnarchiveProject(pId)
+			if err != nil {
+				return p, reportError(err)
+			}
+
+			if err := p.refresh(); err != nil {
+				return p, reportError(err)
+			}
+		case key.Matches(msg, p.keys.Sort):
+			p.sortBy = (p.sortBy + 1) % numProjectSorts
+			p.reverse = false
+			if err := p.refresh(); err != nil {
+				return p, reportError(err)
+			}
+		case key.Matches(msg, p.keys.Reverse):
+			p.reverse = !p.reverse
+			if err := p.refresh(); err != nil {
+				return p, reportError(err)
+			}
+		case key.Matches(msg, p.keys.MoveUp), key.Matches(msg, p.keys.MoveDown):
+			// Moving only makes sense when looking at the manual order
+			pId, ok := p.selectedProject()
+			if !ok || p.sortBy != sortManual || p.reverse {
+				return p, nil
+			}
+
+			delta := 1
+			if key.Matches(msg, p.keys.MoveUp) {
+				delta = -1
+			}
+
+			err := store.MoveProject(pId, delta)
+			if err != nil {
+				return p, reportError(err)
+			}
+
+			// Keep the cursor on the project that moved
+			if err := p.refresh(); err != nil {
+				return p, reportError(err)
+			}
+
+			p.table.SetCursor(p.table.Cursor() + delta)
+		case key.Matches(msg, p.keys.Rename):
+			if _, ok := p.selectedProject(); !ok {
+				return p, nil
+			}
+
+			p.renaming = true
+			p.rename = textinput.New()
+			p.rename.Prompt = "Rename: "
+			p.rename.SetValue(p.projects[p.table.Cursor()].name)
+			p.rename.Focus()
+
+			return p, textinput.Blink
+		case key.Matches(msg, p.keys.Delete):
+			pId, ok := p.selectedProject()
+			if !ok {
+				return p, nil
+			}
+
+			// Give the task count so it's clear what will be lost
+			project := p.projects[p.table.Cursor()]
+			prompt := fmt.Sprintf("Permanently delete %s and its %d tasks?", project.name, project.total())
+
+			var cmd tea.Cmd
+			p.confirm, cmd = Ask(prompt, func() tea.Msg {
+				err := store.DeleteProject(pId)
+				if err != nil {
+					return ErrorMsg{err: err}
+				}
+
+				return RefreshProjectsMsg{}
+			})
+
+			return p, cmd
 		}
 	}
 
 	return p, nil
 }
 
+func (p *ProjectsTable) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return p, tea.Quit
+	case "esc":
+		p.renaming = false
+		return p, nil
+	case "enter":
+		p.renaming = false
+
+		pId, ok := p.selectedProject()
+		if !ok || p.rename.Value() == "" {
+			return p, nil
+		}
+
+		err := store.RenameProject(pId, p.rename.Value())
+		if err != nil {
+			return p, reportError(err)
+		}
+
+		if err := p.refresh(); err != nil {
+			return p, reportError(err)
+		}
+
+		return p, nil
+	}
+
+	var cmd tea.Cmd
+	p.rename, cmd = p.rename.Update(msg)
+
+	return p, cmd
+}
+
+// The id of the highlighted project, if there is one
+func (p *ProjectsTable) selectedProject() (int, bool) {
+	if len(p.projects) == 0 {
+		return 0, false
+	}
+
+	return p.projects[p.table.Cursor()].id, true
+}
+
+// Reload the projects from the db, keeping the cursor in bounds
+func (p *ProjectsTable) refresh() error {
+	p.revision = currentRevision()
+
+	projects, err := loadProjects(p.view)
+	if err != nil {
+		// Try again on the next revision check
+		p.revision = -1
+		return err
+	}
+
+	p.projects = projects
+	sortProjects(p.projects, p.sortBy, p.reverse)
+	p.layout()
+
+	// SetCursor clamps to the rows
+	p.table.SetCursor(p.table.Cursor())
+
+	return nil
+}
+
+// Rebuild the columns and rows for the current width
+func (p *ProjectsTable) layout() {
+	columns, rows := buildTable(p.projects, p.width)
+
+	// Columns and rows have to agree at all times, so clear the rows
+	// before changing the number of columns.
+	p.table.SetRows(nil)
+	p.table.SetColumns(columns)
+	p.table.SetRows(rows)
+}
+
 func (p *ProjectsTable) View() string {
 	var heading string
 	if p.view == archived {
@@ -139,10 +473,44 @@ func (p *ProjectsTable) View() string {
 		heading = "Projects"
 	}
 
+	if p.sortBy != sortManual || p.reverse {
+		direction := "↓"
+		if p.reverse {
+			direction = "↑"
+		}
+
+		heading += fmt.Sprintf(" (sorted by %s %s)", p.sortBy, direction)
+	}
+
+	// The highlighted row takes on the color of its project
+	if len(p.projects) > 0 {
+		s := table.DefaultStyles()
+		s.Header = tableHeaderStyle
+		s.Cell = tableCellStyle
+		s.Selected = s.Selected.
+			Foreground(projectColor(p.projects[p.table.Cursor()].color)).
+			Bold(true)
+		p.table.SetStyles(s)
+	}
+
 	he := centerCtyle.Width(p.width).Render(heading)
 	t := tableStyle.Width(p.width).Align(lipgloss.Center).Render(p.table.View())
-	h := helpStyle.Width(p.width).Align(lipgloss.Center).Render(p.help.View(p.keys))
-	return lipgloss.JoinVertical(lipgloss.Left, he, t, h)
+
+	var h string
+	if p.renaming {
+		h = centerCtyle.Width(p.width).Render(p.rename.View())
+	} else if p.notice != "" {
+		h = helpStyle.Width(p.width).Align(lipgloss.Center).Render(p.notice)
+	} else {
+		h = helpStyle.Width(p.width).Align(lipgloss.Center).Render(p.help.View(p.keys))
+	}
+
+	view := lipgloss.JoinVertical(lipgloss.Left, he, t, h)
+	if p.confirm.Open() {
+		return p.confirm.View(view, p.width, p.height)
+	}
+
+	return view
 }
 
 func (p *ProjectsTable) setViewSize(height int) {
@@ -156,65 +524,160 @@ func (p *ProjectsTable) setViewSize(height int) {
 	p.table.SetHeight(height - h - heading - 6)
 }
 
-func buildTable(s projectStatus) ([]table.Column, []table.Row) {
-	projectDB := GetProjectDB()
-	defer projectDB.db.Close()
-
+// Read the projects with the given status, and the stats of their tasks
+func loadProjects(s projectStatus) ([]projectSummary, error) {
 	// Get all projects from project db
-	projects, err := projectDB.GetByStatus(s)
+	projects, err := store.GetProjectsByStatus(s)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	// The column should be at least as wide as the column title
-	const projectTitle = "Project Name"
-	var longestProjectName int
-	longestProjectName = len(projectTitle)
-
-	taskDB := GetDB()
-	defer taskDB.db.Close()
+	var summaries []projectSummary
+	for _, p := range projects {
+		stats, err := store.GetProjectStats(p.id)
+		if err != nil {
+			return nil, err
+		}
 
-	var rows []table.Row
+		summaries = append(summaries, projectSummary{Project: p, ProjectStats: stats})
+	}
 
-	for _, p := range projects {
-		flpn := float64(longestProjectName)
-		fpn := float64(len(p.name))
-		lpn := math.Max(flpn, fpn)
-		longestProjectName = int(lpn)
+	return summaries, nil
+}
 
-		tasks, err := taskDB.GetProjectTasksByStatus(p.id)
-		if err != nil {
-			log.Fatal(err)
+// Sort projects in place. Every sort has its most useful order first (A-Z,
+// most progress, most recent activity), which reverse flips.
+func sortProjects(projects []projectSummary, by projectSort, reverse bool) {
+	less := func(a, b projectSummary) bool {
+		switch by {
+		case sortName:
+			return strings.ToLower(a.name) < strings.ToLower(b.name)
+		case sortProgress:
+			return a.progress() > b.progress()
+		case sortActivity:
+			return a.lastActivity.After(b.lastActivity)
 		}
 
-		// build the row
-		var row table.Row
-		row = append(row, strconv.Itoa(p.id), p.name)
+		// Manual order is already how the db returns them
+		return false
+	}
 
-		// Add the tasks to the appropriate columns
-		statuses := [3]string{"0", "0", "0"}
-		for _, task := range tasks {
-			statuses[task.status] = strconv.Itoa(task.count)
+	sort.SliceStable(projects, func(i, j int) bool {
+		if reverse {
+			return less(projects[j], projects[i])
 		}
 
-		row = append(row, statuses[0:3]...)
+		return less(projects[i], projects[j])
+	})
+}
 
-		rows = append(rows, row)
+// Render the fraction as a bar of the given width, ending in a percentage
+func progressBar(fraction float64, width int) string {
+	if fraction < 0 {
+		return "-"
+	}
+
+	percent := fmt.Sprintf(" %3.0f%%", fraction*100)
+	barWidth := width - len(percent)
+	if barWidth < 1 {
+		return strings.TrimSpace(percent)
+	}
+
+	filled := int(math.Round(fraction * float64(barWidth)))
+	return strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled) + percent
+}
+
+// Describe how long ago something happened, e.g. "3h ago"
+func timeAgo(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+
+	return t.Format(dateFormat)
+}
+
+// Build the table for the given projects, stretching the name and progress
+// columns to fill the width of the terminal (if it's known yet).
+func buildTable(projects []projectSummary, width int) ([]table.Column, []table.Row) {
+	// The column should be at least as wide as the column title
+	const projectTitle = "Project Name"
+	longestProjectName := len(projectTitle)
+	for _, p := range projects {
+		longestProjectName = max(longestProjectName, lipgloss.Width(p.name))
 	}
 
 	columns := []table.Column{
-		{Title: "ID", Width: 2},
+		{Title: "ID", Width: 3},
+		{Title: "Key", Width: maxPrefixLength},
 		{Title: projectTitle, Width: longestProjectName},
 		{Title: "Todo", Width: 4},
 		{Title: "In Progress", Width: 11},
 		{Title: "Done", Width: 4},
+		{Title: "Progress", Width: minProgressWidth},
+		{Title: "Overdue", Width: 7},
+		{Title: "Last Activity", Width: 13},
+	}
+
+	if width > 0 {
+		// Every cell has a padding of 1 and a border on its right, and
+		// the table itself has a margin of 1 on each side.
+		used := 2
+		for _, c := range columns {
+			used += c.Width + 3
+		}
+
+		// Give the progress bar the spare room, up to a point, and then
+		// take room away from the name if the terminal is too narrow.
+		spare := width - used
+		columns[6].Width = min(minProgressWidth+max(spare, 0), maxProgressWidth)
+		if spare < 0 {
+			columns[2].Width = max(columns[2].Width+spare, len("Project"))
+		}
+	}
+
+	var rows []table.Row
+	for _, p := range projects {
+		// build the row
+		row := table.Row{strconv.Itoa(p.id), p.prefix, p.name}
+
+		// Add the tasks to the appropriate columns
+		for _, count := range p.counts {
+			row = append(row, strconv.Itoa(count))
+		}
+
+		overdue := "-"
+		if p.overdue > 0 {
+			overdue = strconv.Itoa(p.overdue)
+		}
+
+		row = append(
+			row,
+			progressBar(p.progress(), columns[6].Width),
+			overdue,
+			timeAgo(p.lastActivity),
+		)
+
+		rows = append(rows, row)
 	}
 
 	return columns, rows
 }
 
-func NewProjectsTable() *ProjectsTable {
-	columns, rows := buildTable(open)
+func NewProjectsTable(session *Session) *ProjectsTable {
+	projects, err := loadProjects(open)
+	columns, rows := buildTable(projects, 0)
 
 	t := table.New(
 		table.WithColumns(columns),
@@ -224,17 +687,8 @@ func NewProjectsTable() *ProjectsTable {
 
 	// Set table styles by extracting defaults, and the resetting them
 	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(grey).
-		BorderRight(true).
-		BorderBottom(true)
-
-	s.Cell = s.Cell.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(grey).
-		BorderRight(true)
-
+	s.Header = tableHeaderStyle
+	s.Cell = tableCellStyle
 	s.Selected = s.Selected.
 		Foreground(highlightColor).
 		Bold(true)
@@ -242,70 +696,18 @@ func NewProjectsTable() *ProjectsTable {
 	t.SetStyles(s)
 
 	return &ProjectsTable{
-		table: t,
-		keys:  projectListKeys,
-		help:  help.New(),
-	}
-}
-
-type NewProject struct {
-	model  textinput.Model
-	name   string
-	width  int
-	height int
-}
-
-func NewProjectForm(width, height int) *NewProject {
-	t := textinput.New()
-	t.Placeholder = "Project Name"
-	t.Focus()
-	f := &NewProject{model: t, width: width, height: height}
-
-	return f
-}
-
-func (f *NewProject) Init() tea.Cmd {
-	return textinput.Blink
-}
-
-func (f *NewProject) View() string {
-	render := newProjectStyle.Render(f.model.View())
-	return lipgloss.Place(f.width, f.height, lipgloss.Center, lipgloss.Center, render)
-}
-
-func (f *NewProject) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			return f, tea.Quit
-		case "ctrl+b":
-			return models[projects], nil
-		case "enter":
-			projectDB := GetProjectDB()
-			defer projectDB.db.Close()
-
-			_, err := projectDB.Insert(f.model.Value())
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			return models[projects], f.RefreshProjects
-		default:
-			// Pass all keystrokes to textinput
-			f.model, cmd = f.model.Update(msg)
-
-			return f, cmd
-		}
+		session:  session,
+		projects: projects,
+		table:    t,
+		keys:     projectListKeys,
+		help:     help.New(),
+		err:      err,
 	}
-
-	return f, nil
 }
 
 // Simple message to tell the project model to build the rows again
 type RefreshProjectsMsg struct{}
 
-func (f *NewProject) RefreshProjects() tea.Msg {
+func (f *ProjectForm) RefreshProjects() tea.Msg {
 	return RefreshProjectsMsg{}
 }
@@ -2,7 +2,12 @@ package main
 
 import (
 	"database/sql"
-	"log"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 type ProjectDB struct {
@@ -16,6 +21,15 @@ const (
 	archived
 )
 
+// Longest prefix allowed for task keys, e.g. "KANBAN-12"
+const maxPrefixLength = 6
+
+// Returned by Insert when another project already has the prefix
+var ErrPrefixTaken = errors.New("prefix is already used by another project")
+
+const projectSelect = `SELECT id, name, sort_order, status, prefix,
+    description, color, owner, target_date, lanes FROM projects`
+
 func (p *ProjectDB) CreateTable() error {
 	// Create our table if it doesn't exist.
 	// A project should have the following data:
@@ -23,62 +37,225 @@ func (p *ProjectDB) CreateTable() error {
 	// name
 	// sort_order
 	// status
+	// prefix --> used for task keys, e.g. API-17
+	// task_seq --> the last task number handed out in this project
+	// description
+	// color --> hex or ANSI color, used for the board header
+	// owner
+	// target_date --> optional, formatted as dateFormat
+	// lanes --> comma separated lane titles, empty for the usual ones
 	createStatement := `
     CREATE TABLE IF NOT EXISTS projects (
         id INTEGER PRIMARY KEY AUTOINCREMENT,
         name TEXT,
         sort_order INTEGER,
-        status INTEGER DEFAULT 0
+        status INTEGER DEFAULT 0,
+        prefix TEXT,
+        task_seq INTEGER NOT NULL DEFAULT 0,
+        description TEXT NOT NULL DEFAULT '',
+        color TEXT NOT NULL DEFAULT '',
+        owner TEXT NOT NULL DEFAULT '',
+        target_date TEXT,
+        lanes TEXT NOT NULL DEFAULT ''
     )
     `
 
 	_, err := p.db.Exec(createStatement)
+	if err != nil {
+		return err
+	}
+
+	columns := [][2]string{
+		{"description", "TEXT NOT NULL DEFAULT ''"},
+		{"color", "TEXT NOT NULL DEFAULT ''"},
+		{"owner", "TEXT NOT NULL DEFAULT ''"},
+		{"target_date", "TEXT"},
+		{"lanes", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, c := range columns {
+		if _, err := addColumn(p.db, "projects", c[0], c[1]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := addColumn(p.db, "projects", "task_seq", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	added, err := addColumn(p.db, "projects", "prefix", "TEXT")
+	if err != nil {
+		return err
+	}
+
+	if added {
+		if err := p.backfillPrefixes(); err != nil {
+			return err
+		}
+	}
+
+	return p.createPrefixIndex()
+}
+
+// Projects created before task keys existed get a prefix made from their name
+func (p *ProjectDB) backfillPrefixes() error {
+	projects, err := p.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		prefix, err := p.freePrefix(DefaultPrefix(project.name), project.id)
+		if err != nil {
+			return err
+		}
+
+		_, err = p.db.Exec("UPDATE projects SET prefix = ? WHERE id = ?", prefix, project.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Prefixes are kept unique by an index, so two people creating projects at
+// once can't both get the same one. Databases from before the index may
+// already have a duplicate, which is given a free prefix first.
+func (p *ProjectDB) createPrefixIndex() error {
+	rows, err := p.db.Query(
+		`SELECT id, prefix FROM projects AS p WHERE EXISTS
+            (SELECT 1 FROM projects AS other WHERE other.prefix = p.prefix AND other.id < p.id)`,
+	)
+	if err != nil {
+		return err
+	}
+
+	duplicates := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var prefix string
+		if err := rows.Scan(&id, &prefix); err != nil {
+			rows.Close()
+			return err
+		}
+
+		duplicates[id] = prefix
+	}
+	rows.Close()
+
+	for id, base := range duplicates {
+		prefix, err := p.freePrefix(base, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := p.db.Exec("UPDATE projects SET prefix = ? WHERE id = ?", prefix, id); err != nil {
+			return err
+		}
+	}
+
+	_, err = p.db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS projects_prefix ON projects (prefix)")
 
 	return err
 }
 
-func (p *ProjectDB) GetAll() ([]Project, error) {
-	rows, err := p.db.Query("SELECT * FROM projects")
+// The prefix if nobody has it yet, or else the prefix with the project's id
+// on the end. It's cut short to make room for the id, so the result is
+// still a valid prefix.
+func (p *ProjectDB) freePrefix(base string, id int) (string, error) {
+	prefix := base
+	for n := id; ; n++ {
+		taken, err := p.PrefixExists(prefix)
+		if err != nil || !taken {
+			return prefix, err
+		}
+
+		suffix := strconv.Itoa(n)
+		kept := []rune(base)
+		if keep := max(maxPrefixLength-len(suffix), 1); len(kept) > keep {
+			kept = kept[:keep]
+		}
+
+		prefix = string(kept) + suffix
+	}
+}
+
+func scanProject(row scanner) (Project, error) {
+	var project Project
+	var prefix, target sql.NullString
+	var lanes string
+	err := row.Scan(
+		&project.id,
+		&project.name,
+		&project.order,
+		&project.status,
+		&prefix,
+		&project.description,
+		&project.color,
+		&project.owner,
+		&target,
+		&lanes,
+	)
 	if err != nil {
-		return nil, err
+		return project, err
+	}
+
+	if project.lanes, err = parseLanes(lanes); err != nil {
+		return project, err
 	}
 
+	project.prefix = prefix.String
+	project.targetDate, err = parseDate(target.String)
+
+	return project, err
+}
+
+func scanProjects(rows *sql.Rows) ([]Project, error) {
+	defer rows.Close()
+
 	var projects []Project
 	for rows.Next() {
-		var project Project
-		rows.Scan(
-			&project.id,
-			&project.name,
-			&project.order,
-			&project.status,
-		)
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
 
 		projects = append(projects, project)
 	}
 
-	return projects, nil
+	return projects, rows.Err()
 }
 
-func (p *ProjectDB) GetByStatus(s projectStatus) ([]Project, error) {
-	rows, err := p.db.Query("SELECT * FROM projects WHERE status = ?", s)
+func (p *ProjectDB) Get(id int) (Project, error) {
+	return scanProject(p.db.QueryRow(projectSelect+" WHERE id = ?", id))
+}
+
+// Look up a project by its task key prefix ("API") or, failing that, its
+// database id
+func (p *ProjectDB) Resolve(ref string) (Project, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return p.Get(id)
+	}
+
+	return scanProject(p.db.QueryRow(projectSelect+" WHERE prefix = ?", strings.ToUpper(ref)))
+}
+
+func (p *ProjectDB) GetAll() ([]Project, error) {
+	rows, err := p.db.Query(projectSelect + " ORDER BY sort_order, id")
 	if err != nil {
 		return nil, err
 	}
 
-	var projects []Project
-	for rows.Next() {
-		var project Project
-		rows.Scan(
-			&project.id,
-			&project.name,
-			&project.order,
-			&project.status,
-		)
+	return scanProjects(rows)
+}
 
-		projects = append(projects, project)
+func (p *ProjectDB) GetByStatus(s projectStatus) ([]Project, error) {
+	rows, err := p.db.Query(projectSelect+" WHERE status = ? ORDER BY sort_order, id", s)
+	if err != nil {
+		return nil, err
 	}
 
-	return projects, nil
+	return scanProjects(rows)
 }
 
 func (p *ProjectDB) GetHighestOrder() (int, error) {
@@ -94,18 +271,82 @@ func (p *ProjectDB) GetHighestOrder() (int, error) {
 	return highestOrder, nil
 }
 
-func (p *ProjectDB) Insert(projectName string) (sql.Result, error) {
-	newOrder, err := p.GetHighestOrder()
+func (p *ProjectDB) PrefixExists(prefix string) (bool, error) {
+	var count int
+	err := p.db.QueryRow("SELECT COUNT(id) FROM projects WHERE prefix = ?", prefix).Scan(&count)
+
+	return count > 0, err
+}
+
+// Insert the project after all the others, with any starter tasks in it,
+// all in one transaction. Returns the id of the new project, or
+// ErrPrefixTaken if its prefix isn't free.
+func (p *ProjectDB) Insert(project Project, tasks []Task) (int, error) {
+	tx, err := p.db.Begin()
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	result, err := p.db.Exec("INSERT INTO projects (name, sort_order) VALUES(?, ?)", projectName, newOrder)
+	var newOrder int
+	err = tx.QueryRow("SELECT COALESCE(MAX(sort_order), 0) FROM projects").Scan(&newOrder)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
-	return result, nil
+	result, err := tx.Exec(
+		`INSERT INTO projects (name, sort_order, prefix, description, color, owner, target_date, lanes)
+        VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+		project.name,
+		newOrder+1,
+		project.prefix,
+		project.description,
+		project.color,
+		project.owner,
+		formatDate(project.targetDate),
+		formatLanes(project.lanes),
+	)
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return 0, ErrPrefixTaken
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, task := range tasks {
+		task.ProjectId = int(id)
+		if _, err := insertInTx(tx, task); err != nil {
+			return 0, err
+		}
+	}
+
+	return int(id), tx.Commit()
+}
+
+// Update the editable details of a project. The prefix is left alone, since
+// changing it would change the key of every task in the project.
+func (p *ProjectDB) Update(project Project) error {
+	_, err := p.db.Exec(
+		`UPDATE projects SET name = ?, description = ?, color = ?, owner = ?, target_date = ?, lanes = ?
+        WHERE id = ?`,
+		project.name,
+		project.description,
+		project.color,
+		project.owner,
+		formatDate(project.targetDate),
+		formatLanes(project.lanes),
+		project.id,
+	)
+
+	return err
 }
 
 func (p *ProjectDB) ArchiveProject(id int) error {
@@ -116,3 +357,176 @@ func (p *ProjectDB) ArchiveProject(id int) error {
 
 	return nil
 }
+
+// Unarchived projects go to the bottom of the open projects
+func (p *ProjectDB) UnarchiveProject(id int) error {
+	highestOrder, err := p.GetHighestOrder()
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.Exec("UPDATE projects SET status = ?, sort_order = ? WHERE id = ?", open, highestOrder+1, id)
+
+	return err
+}
+
+func (p *ProjectDB) Rename(id int, name string) error {
+	_, err := p.db.Exec("UPDATE projects SET name = ? WHERE id = ?", name, id)
+
+	return err
+}
+
+// Move a project up (negative delta) or down (positive delta) among the
+// projects with the same status. The sort order of those projects is
+// rewritten as a whole, since older databases have every project at the
+// same sort order.
+func (p *ProjectDB) Move(id int, delta int) error {
+	var s projectStatus
+	err := p.db.QueryRow("SELECT status FROM projects WHERE id = ?", id).Scan(&s)
+	if err != nil {
+		return err
+	}
+
+	projects, err := p.GetByStatus(s)
+	if err != nil {
+		return err
+	}
+
+	from := -1
+	for i, project := range projects {
+		if project.id == id {
+			from = i
+		}
+	}
+
+	to := from + delta
+	if from < 0 || to < 0 || to >= len(projects) {
+		return nil
+	}
+
+	projects[from], projects[to] = projects[to], projects[from]
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, project := range projects {
+		_, err := tx.Exec("UPDATE projects SET sort_order = ? WHERE id = ?", i+1, project.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Permanently delete a project along with all of its tasks
+func (p *ProjectDB) Delete(id int) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`DELETE FROM task_dependencies
+        WHERE task_id IN (SELECT id FROM tasks WHERE project_id = ?)
+        OR blocker_id IN (SELECT id FROM tasks WHERE project_id = ?)`,
+		id,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM time_entries WHERE task_id IN (SELECT id FROM tasks WHERE project_id = ?)", id)
+	if err != nil {
+		return err
+	}
+
+	// Epics can have children in other projects, which stay
+	_, err = tx.Exec("UPDATE tasks SET parent_id = NULL WHERE parent_id IN (SELECT id FROM tasks WHERE project_id = ?)", id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tasks WHERE project_id = ?", id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM sprints WHERE project_id = ?", id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM projects WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Suggest a task key prefix from a project name: the initials of a
+// multi-word name ("Mobile App" -> "MA"), or the start of a single word
+// ("Website" -> "WEB"). Anything ValidPrefix wouldn't take is left out,
+// like leading digits ("2024 Roadmap" -> "R"), and "P" stands in if
+// nothing is left.
+func DefaultPrefix(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var prefix string
+	if len(words) > 1 {
+		for _, w := range words {
+			prefix += string([]rune(w)[0])
+		}
+	} else if len(words) == 1 {
+		prefix = words[0]
+		if len([]rune(prefix)) > 3 {
+			prefix = string([]rune(prefix)[:3])
+		}
+	}
+
+	// Letters without an upper case, like those of many non-Latin
+	// scripts, can't go in a key
+	prefix = strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) || unicode.IsDigit(r) {
+			return r
+		}
+
+		return -1
+	}, strings.ToUpper(prefix))
+
+	prefix = strings.TrimLeftFunc(prefix, unicode.IsDigit)
+	if len([]rune(prefix)) > maxPrefixLength {
+		prefix = string([]rune(prefix)[:maxPrefixLength])
+	}
+
+	if !ValidPrefix(prefix) {
+		return "P"
+	}
+
+	return prefix
+}
+
+// Prefixes are upper case letters and digits, starting with a letter, so
+// that keys like "API-17" can be split unambiguously on the last dash.
+func ValidPrefix(prefix string) bool {
+	if prefix == "" || len([]rune(prefix)) > maxPrefixLength {
+		return false
+	}
+
+	for i, r := range prefix {
+		if i == 0 && !unicode.IsUpper(r) {
+			return false
+		}
+
+		if !unicode.IsUpper(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
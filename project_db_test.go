@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestDefaultPrefix(t *testing.T) {
+	tests := map[string]string{
+		"Mobile App":           "MA",
+		"Website":              "WEB",
+		"api":                  "API",
+		"2024 Roadmap":         "R",
+		"2024":                 "P",
+		"项目":                   "P",
+		"Café Menu":            "CM",
+		"":                     "P",
+		"Q3 planning, 2nd try": "QP2T",
+	}
+
+	for name, want := range tests {
+		got := DefaultPrefix(name)
+		if got != want {
+			t.Errorf("DefaultPrefix(%q) = %q, want %q", name, got, want)
+		}
+
+		if !ValidPrefix(got) {
+			t.Errorf("DefaultPrefix(%q) = %q, which isn't a valid prefix", name, got)
+		}
+	}
+}
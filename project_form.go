@@ -0,0 +1,293 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fields of the project form, in tab order
+const (
+	projectNameField = iota
+	projectPrefixField
+	projectDescField
+	projectColorField
+	projectOwnerField
+	projectTargetField
+	projectLanesField
+	numProjectFields
+)
+
+// Form for creating a new project, or editing the details of an existing one
+type ProjectForm struct {
+	session *Session
+	project Project
+	editing bool
+	fields  []textinput.Model
+	focused int
+	err     string
+	width   int
+	height  int
+
+	// A new project made from a template gets its starter tasks
+	template *ProjectTemplate
+}
+
+func newProjectFields() []textinput.Model {
+	fields := make([]textinput.Model, numProjectFields)
+	for i := range fields {
+		fields[i] = textinput.New()
+	}
+
+	fields[projectNameField].Placeholder = "Project Name"
+	fields[projectNameField].Prompt = "Name: "
+
+	// The prefix is optional, and suggested from the name as it's typed
+	fields[projectPrefixField].Prompt = "Key prefix: "
+	fields[projectPrefixField].CharLimit = maxPrefixLength
+
+	fields[projectDescField].Prompt = "Description: "
+	fields[projectDescField].Placeholder = "What is this project about?"
+
+	fields[projectColorField].Prompt = "Color: "
+	fields[projectColorField].Placeholder = highlight
+
+	fields[projectOwnerField].Prompt = "Owner: "
+
+	fields[projectTargetField].Prompt = "Target date: "
+	fields[projectTargetField].Placeholder = dateFormat
+
+	fields[projectLanesField].Prompt = "Lanes: "
+	fields[projectLanesField].Placeholder = "Todo, In Progress, Done"
+
+	return fields
+}
+
+func NewProjectForm(session *Session, width, height int) *ProjectForm {
+	f := &ProjectForm{session: session, fields: newProjectFields(), width: width, height: height}
+	f.fields[projectNameField].Focus()
+
+	return f
+}
+
+func EditProjectForm(session *Session, project Project, width, height int) *ProjectForm {
+	f := &ProjectForm{
+		session: session,
+		project: project,
+		editing: true,
+		fields:  newProjectFields(),
+		width:   width,
+		height:  height,
+	}
+
+	f.fields[projectNameField].SetValue(project.name)
+	f.fields[projectPrefixField].SetValue(project.prefix)
+	f.fields[projectDescField].SetValue(project.description)
+	f.fields[projectColorField].SetValue(project.color)
+	f.fields[projectOwnerField].SetValue(project.owner)
+	if !project.targetDate.IsZero() {
+		f.fields[projectTargetField].SetValue(project.targetDate.Format(dateFormat))
+	}
+	f.fields[projectLanesField].SetValue(formatLanes(project.lanes))
+
+	f.fields[projectNameField].Focus()
+
+	return f
+}
+
+func (f *ProjectForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *ProjectForm) View() string {
+	var views []string
+	for i, field := range f.fields {
+		// Changing the prefix would change every task key, so it's
+		// only shown once the project exists.
+		if i == projectPrefixField && f.editing {
+			views = append(views, field.Prompt+helpStyle.Render(field.Value()))
+			continue
+		}
+
+		views = append(views, field.View())
+	}
+
+	if f.template != nil {
+		views = append(views, fmt.Sprintf("Template: %s (%s)", f.template.Name, taskCount(len(f.template.Tasks))))
+	} else if !f.editing {
+		views = append(views, helpStyle.Render("ctrl+t: start from a template"))
+	}
+
+	if f.err != "" {
+		views = append(views, errorStyle.Render(f.err))
+	}
+
+	render := newProjectStyle.Render(lipgloss.JoinVertical(lipgloss.Left, views...))
+	return lipgloss.Place(f.width, f.height, lipgloss.Center, lipgloss.Center, render)
+}
+
+// Move focus to the next (or previous) field that can be edited
+func (f *ProjectForm) cycleFocus(delta int) {
+	f.fields[f.focused].Blur()
+
+	for {
+		f.focused = (f.focused + delta + numProjectFields) % numProjectFields
+		if !(f.editing && f.focused == projectPrefixField) {
+			break
+		}
+	}
+
+	f.fields[f.focused].Focus()
+}
+
+func (f *ProjectForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case ProjectTemplateMsg:
+		f.useTemplate(msg.template)
+		return f, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return f, tea.Quit
+		case "ctrl+b", "esc":
+			return f, Pop()
+		case "ctrl+t":
+			if f.editing {
+				return f, nil
+			}
+
+			templates, err := allTemplates()
+			if err != nil {
+				f.err = err.Error()
+				return f, nil
+			}
+
+			picker := NewPicker("Start from which template?", "template", projectTemplateItems(templates), f.width, f.height, func(item list.Item) tea.Cmd {
+				return func() tea.Msg {
+					return ProjectTemplateMsg{template: item.(projectTemplateItem).template}
+				}
+			})
+
+			return f, Push(picker)
+		case "tab", "down":
+			f.cycleFocus(1)
+			return f, textinput.Blink
+		case "shift+tab", "up":
+			f.cycleFocus(-1)
+			return f, textinput.Blink
+		case "enter":
+			return f.save()
+		default:
+			// Pass all other keystrokes to the focused textinput
+			f.fields[f.focused], cmd = f.fields[f.focused].Update(msg)
+			if f.focused == projectNameField {
+				f.fields[projectPrefixField].Placeholder = DefaultPrefix(f.fields[projectNameField].Value())
+			}
+
+			return f, cmd
+		}
+	}
+
+	return f, nil
+}
+
+// Sent by the template picker with the template to start the project from
+type ProjectTemplateMsg struct {
+	template ProjectTemplate
+}
+
+// Fill in the description and lanes from the template, keeping the name
+// that was typed in
+func (f *ProjectForm) useTemplate(t ProjectTemplate) {
+	f.template = &t
+	f.fields[projectDescField].SetValue(t.Description)
+	f.fields[projectLanesField].SetValue(formatLanes(t.Lanes))
+	f.err = ""
+}
+
+// Validate the fields, then insert or update the project
+func (f *ProjectForm) save() (tea.Model, tea.Cmd) {
+	project := f.project
+	project.name = strings.TrimSpace(f.fields[projectNameField].Value())
+	project.description = f.fields[projectDescField].Value()
+	project.color = strings.TrimSpace(f.fields[projectColorField].Value())
+	project.owner = strings.TrimSpace(f.fields[projectOwnerField].Value())
+
+	if project.name == "" {
+		f.err = "A project needs a name"
+		return f, nil
+	}
+
+	if project.color != "" && !ValidColor(project.color) {
+		f.err = "Color must be hex (#FF8800) or an ANSI color number (0-255)"
+		return f, nil
+	}
+
+	target, err := parseDate(strings.TrimSpace(f.fields[projectTargetField].Value()))
+	if err != nil {
+		f.err = fmt.Sprintf("Target date must look like %s", dateFormat)
+		return f, nil
+	}
+	project.targetDate = target
+
+	if project.lanes, err = parseLanes(f.fields[projectLanesField].Value()); err != nil {
+		f.err = err.Error()
+		return f, nil
+	}
+
+	if f.editing {
+		err := store.UpdateProject(project)
+		if err != nil {
+			f.err = err.Error()
+			return f, nil
+		}
+
+		return f, tea.Sequence(Pop(), f.RefreshProjects)
+	}
+
+	project.prefix = strings.ToUpper(f.fields[projectPrefixField].Value())
+	if project.prefix == "" {
+		project.prefix = DefaultPrefix(project.name)
+	}
+
+	if !ValidPrefix(project.prefix) {
+		f.err = fmt.Sprintf("Key prefix must be letters and digits, at most %d long", maxPrefixLength)
+		return f, nil
+	}
+
+	taken, err := store.PrefixExists(project.prefix)
+	if err != nil {
+		f.err = err.Error()
+		return f, nil
+	}
+
+	if taken {
+		f.err = fmt.Sprintf("Key prefix %s is already used by another project", project.prefix)
+		return f, nil
+	}
+
+	var tasks []Task
+	if f.template != nil {
+		tasks = starterTasks(*f.template, f.session.user.id)
+	}
+
+	// Someone else may have taken the prefix since it was checked
+	_, err = store.InsertProject(project, tasks)
+	if errors.Is(err, ErrPrefixTaken) {
+		f.err = fmt.Sprintf("Key prefix %s is already used by another project", project.prefix)
+		return f, nil
+	}
+
+	if err != nil {
+		f.err = err.Error()
+		return f, nil
+	}
+
+	return f, tea.Sequence(Pop(), f.RefreshProjects)
+}
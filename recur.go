@@ -0,0 +1,479 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type recurKind int
+
+const (
+	recurDaily recurKind = iota
+	recurWeekly
+	recurMonthly
+	recurCron
+)
+
+var weekdayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// How long to look ahead for the next date of a cron expression before
+// deciding it never comes, long enough to take in a 29th of February
+const cronHorizon = 8 * 366
+
+// When a recurring task comes round again. Written as one of:
+//
+//	daily
+//	weekly               the same weekday as the last due date
+//	weekly mon,thu
+//	monthly              the same day of the month as the last due date
+//	monthly 15
+//	0 9 * * 1-5          cron, of which only the day fields count
+type Recurrence struct {
+	kind     recurKind
+	weekdays [7]bool // Weekly, none means the weekday of the last date
+	day      int     // Monthly, zero means the day of the last date
+
+	// Cron, each indexed by its own value
+	days       [32]bool
+	months     [13]bool
+	cronDays   [7]bool
+	anyDay     bool // The day of the month field was *
+	anyWeekday bool // The day of the week field was *
+	text       string
+}
+
+func ParseRecurrence(s string) (Recurrence, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	r := Recurrence{text: strings.Join(fields, " ")}
+
+	if len(fields) == 0 {
+		return r, errors.New("Empty recurrence")
+	}
+
+	switch fields[0] {
+	case "daily":
+		r.kind = recurDaily
+		if len(fields) > 1 {
+			return r, errors.New("daily doesn't take anything after it")
+		}
+	case "weekly":
+		r.kind = recurWeekly
+		if len(fields) > 2 {
+			return r, errors.New("weekly takes a list of days like mon,thu")
+		}
+
+		if len(fields) == 2 {
+			for _, name := range strings.Split(fields[1], ",") {
+				day, err := parseWeekday(name)
+				if err != nil {
+					return r, err
+				}
+
+				r.weekdays[day] = true
+			}
+
+			// Stored with the short names, in order
+			var days []string
+			for i, on := range r.weekdays {
+				if on {
+					days = append(days, weekdayNames[i])
+				}
+			}
+
+			r.text = "weekly " + strings.Join(days, ",")
+		}
+	case "monthly":
+		r.kind = recurMonthly
+		if len(fields) > 2 {
+			return r, errors.New("monthly takes a day of the month like 15")
+		}
+
+		if len(fields) == 2 {
+			day, err := strconv.Atoi(fields[1])
+			if err != nil || day < 1 || day > 31 {
+				return r, fmt.Errorf("Invalid day of the month %s", fields[1])
+			}
+
+			r.day = day
+		}
+	default:
+		if err := r.parseCron(fields); err != nil {
+			return r, err
+		}
+	}
+
+	// A rule that never comes round again is no use
+	if r.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)).IsZero() {
+		return r, fmt.Errorf("%s never comes round", r.text)
+	}
+
+	return r, nil
+}
+
+// Day names can be written in full, e.g. "monday"
+func parseWeekday(name string) (int, error) {
+	for i, day := range weekdayNames {
+		if name == day || name == strings.ToLower(time.Weekday(i).String()) {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Invalid day %s, use mon, tue and so on", name)
+}
+
+func (r *Recurrence) parseCron(fields []string) error {
+	if len(fields) != 5 {
+		return errors.New("Repeat daily, weekly, monthly or on a cron expression like 0 9 * * 1-5")
+	}
+
+	r.kind = recurCron
+
+	// Due dates have no time of day, but the fields still have to make sense
+	var minutes [60]bool
+	var hours [24]bool
+	var weekdays [8]bool // Both 0 and 7 are Sunday
+	fieldSets := []struct {
+		set      []bool
+		min, max int
+	}{
+		{minutes[:], 0, 59},
+		{hours[:], 0, 23},
+		{r.days[:], 1, 31},
+		{r.months[:], 1, 12},
+		{weekdays[:], 0, 7},
+	}
+
+	for i, f := range fieldSets {
+		if err := parseCronField(fields[i], f.set, f.min, f.max); err != nil {
+			return err
+		}
+	}
+
+	copy(r.cronDays[:], weekdays[:7])
+	r.cronDays[0] = r.cronDays[0] || weekdays[7]
+
+	r.anyDay = fields[2] == "*"
+	r.anyWeekday = fields[4] == "*"
+
+	return nil
+}
+
+// Fill in the set from a cron field: *, numbers, ranges like 1-5, lists of
+// those, and steps like */2 or 1-10/3
+func parseCronField(field string, set []bool, min, max int) error {
+	invalid := fmt.Errorf("Invalid cron field %s", field)
+
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return invalid
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			loText, hiText, isRange := strings.Cut(rng, "-")
+
+			var err error
+			if lo, err = strconv.Atoi(loText); err != nil {
+				return invalid
+			}
+
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiText); err != nil {
+					return invalid
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return invalid
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+
+	return nil
+}
+
+// The rule as it's stored, e.g. "weekly mon,thu"
+func (r Recurrence) String() string {
+	return r.text
+}
+
+// The first date the rule comes round on after the given one, or the zero
+// time if it never does
+func (r Recurrence) Next(after time.Time) time.Time {
+	y, m, d := after.Date()
+	after = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+
+	switch r.kind {
+	case recurDaily:
+		return after.AddDate(0, 0, 1)
+	case recurWeekly:
+		if r.weekdays == [7]bool{} {
+			return after.AddDate(0, 0, 7)
+		}
+
+		for i := 1; i <= 7; i++ {
+			next := after.AddDate(0, 0, i)
+			if r.weekdays[next.Weekday()] {
+				return next
+			}
+		}
+	case recurMonthly:
+		day := r.day
+		if day == 0 {
+			day = d
+		}
+
+		// Later this month, or else next month. Short months get
+		// their last day instead.
+		if day > d && d < daysIn(y, m) {
+			return time.Date(y, m, min(day, daysIn(y, m)), 0, 0, 0, 0, time.Local)
+		}
+
+		next := time.Date(y, m+1, 1, 0, 0, 0, 0, time.Local)
+		return time.Date(next.Year(), next.Month(), min(day, daysIn(next.Year(), next.Month())), 0, 0, 0, 0, time.Local)
+	case recurCron:
+		for i := 1; i <= cronHorizon; i++ {
+			next := after.AddDate(0, 0, i)
+			if r.cronMatches(next) {
+				return next
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// Like cron, when both day fields are restricted either one will do
+func (r Recurrence) cronMatches(t time.Time) bool {
+	if !r.months[t.Month()] {
+		return false
+	}
+
+	day := r.days[t.Day()]
+	weekday := r.cronDays[t.Weekday()]
+
+	switch {
+	case r.anyDay && r.anyWeekday:
+		return true
+	case r.anyDay:
+		return weekday
+	case r.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.Local).Day()
+}
+
+// A plain monthly rule follows the day of the last due date, so a short
+// month would pull the series back for good: the 31st, then the 28th from
+// February on. Instead the day the series is on is written into the rule
+// the next instance gets, so it stays on that day.
+func anchoredRecurrence(task Task) string {
+	r, err := ParseRecurrence(task.Recurrence)
+	if err != nil || r.kind != recurMonthly || r.day != 0 || task.Due.IsZero() {
+		return task.Recurrence
+	}
+
+	return fmt.Sprintf("monthly %d", task.Due.Day())
+}
+
+// The due date of the next instance of a recurring task: the next date after
+// its own due date, or after today if it has none
+func nextDue(task Task) (time.Time, error) {
+	r, err := ParseRecurrence(anchoredRecurrence(task))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	after := task.Due
+	if after.IsZero() {
+		after = time.Now()
+	}
+
+	return r.Next(after), nil
+}
+
+// Make the next instance of a recurring task, due on the given date, and hand
+// it the recurrence. Only the latest instance of a series has it, so each
+// one is only followed once.
+func spawnInTx(tx *sql.Tx, task Task, due time.Time) (int, error) {
+	next := Task{
+		Name:       task.Name,
+		Info:       task.Info,
+		Status:     todo,
+		ProjectId:  task.ProjectId,
+		Due:        due,
+		Assignee:   task.Assignee,
+		CreatedBy:  task.CreatedBy,
+		Priority:   task.Priority,
+		Tags:       task.Tags,
+		Recurrence: anchoredRecurrence(task),
+		Estimate:   task.Estimate,
+		ParentId:   task.ParentId,
+	}
+
+	result, err := insertInTx(tx, next)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE tasks SET recurrence = NULL, version = version + 1, updated_at = datetime('now') WHERE id = ?",
+		task.Id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+
+	return int(id), err
+}
+
+// Follow up a recurring task that's done with its next instance, returning
+// its id
+func recurInTx(tx *sql.Tx, task Task) (int, error) {
+	due, err := nextDue(task)
+	if err != nil {
+		return 0, err
+	}
+
+	return spawnInTx(tx, task, due)
+}
+
+// Make the instances of recurring tasks that should be there by now but
+// aren't: the next one of any that's done (moved to done some other way than
+// NextStatus), and one for the latest date that has come round since the
+// latest instance was due. The dates missed before that are skipped, so a
+// series left alone for a while doesn't come back as a pile of tasks.
+// Returns the new tasks. Archived tasks and projects are left alone.
+func (t *TaskDB) CatchUp() ([]Task, error) {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Read in the transaction, which takes the write lock up front, so a
+	// series can't be moved on by someone else before it's caught up
+	rows, err := tx.Query(
+		taskSelect+" WHERE tasks.recurrence IS NOT NULL AND NOT tasks.archived AND projects.status = ?",
+		open,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, task := range series {
+		r, err := ParseRecurrence(anchoredRecurrence(task))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", task.Key(), err)
+		}
+
+		// A task that's done is due a next instance whatever its date
+		due := task.Due
+		spawn := task.Status == done
+		if spawn {
+			if due, err = nextDue(task); err != nil {
+				return nil, err
+			}
+		}
+
+		// On to the latest date that has come round. Without a due
+		// date, nothing has been missed.
+		for !due.IsZero() {
+			next := r.Next(due)
+			if next.IsZero() || next.Format(dateFormat) > today() {
+				break
+			}
+
+			due = next
+			spawn = true
+		}
+
+		if !spawn {
+			continue
+		}
+
+		id, err := spawnInTx(tx, task, due)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	for _, id := range ids {
+		task, err := t.Get(id)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// `kanban-cli recur run`, to be run from cron or by hand after time away
+func recur(args []string) error {
+	flags := flag.NewFlagSet("recur", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: kanban-cli recur run")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.Arg(0) != "run" || flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("unknown recur command")
+	}
+
+	tasks, err := store.RunRecurrences()
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		fmt.Printf("Created %s %s, due %s\n", task.Key(), task.Name, task.Due.Format(dateFormat))
+	}
+
+	if len(tasks) == 0 {
+		fmt.Println("Nothing to catch up on")
+	}
+
+	return nil
+}
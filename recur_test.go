@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, err := time.ParseInLocation(dateFormat, s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func TestParseRecurrence(t *testing.T) {
+	// An empty want means it's refused
+	tests := map[string]string{
+		"daily":               "daily",
+		"  Weekly  ":          "weekly",
+		"weekly thursday,mon": "weekly mon,thu",
+		"monthly":             "monthly",
+		"monthly 31":          "monthly 31",
+		"0 9 * * 1-5":         "0 9 * * 1-5",
+		"":                    "",
+		"hourly":              "",
+		"daily 2":             "",
+		"weekly funday":       "",
+		"monthly 32":          "",
+		"monthly first":       "",
+		// There's never a 30th of February
+		"0 0 30 2 *": "",
+	}
+
+	for in, want := range tests {
+		r, err := ParseRecurrence(in)
+		if want == "" {
+			if err == nil {
+				t.Errorf("ParseRecurrence(%q) = %q, want an error", in, r)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q): %v", in, err)
+			continue
+		}
+
+		if r.String() != want {
+			t.Errorf("ParseRecurrence(%q) = %q, want %q", in, r, want)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		rule, after, want string
+	}{
+		{"daily", "2024-02-28", "2024-02-29"},
+		// 2024-01-03 is a Wednesday
+		{"weekly", "2024-01-03", "2024-01-10"},
+		{"weekly mon,thu", "2024-01-03", "2024-01-04"},
+		{"weekly mon,thu", "2024-01-04", "2024-01-08"},
+		{"monthly", "2024-01-15", "2024-02-15"},
+		{"monthly 20", "2024-01-15", "2024-01-20"},
+		{"monthly 10", "2024-01-15", "2024-02-10"},
+		// Short months get their last day, and the next one the day
+		// asked for
+		{"monthly 31", "2024-01-31", "2024-02-29"},
+		{"monthly 31", "2024-02-29", "2024-03-31"},
+		{"monthly 31", "2023-01-31", "2023-02-28"},
+		{"0 9 * * 1-5", "2024-01-05", "2024-01-08"},
+		{"0 9 29 2 *", "2024-03-01", "2028-02-29"},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q): %v", tt.rule, err)
+		}
+
+		got := r.Next(date(tt.after)).Format(dateFormat)
+		if got != tt.want {
+			t.Errorf("%q after %s = %s, want %s", tt.rule, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	s := newTestStore(t)
+	project := addTestProject(t, s, "OPS")
+
+	// Missed ten times over, which comes back as one instance, not ten
+	missed := NewTask(todo, "Check backups", "", 0, project.id)
+	missed.Recurrence = "daily"
+	missed.Due = time.Now().AddDate(0, 0, -10)
+	missed = addTestTask(t, s, missed)
+
+	// Done some other way than NextStatus, so its next instance is due
+	y, m, _ := time.Now().Date()
+	finished := NewTask(done, "Pay rent", "", 0, project.id)
+	finished.Recurrence = "monthly"
+	finished.Due = time.Date(y, m+1, 1, 0, 0, 0, 0, time.Local)
+	finished = addTestTask(t, s, finished)
+
+	// Nothing to catch up on
+	upcoming := NewTask(todo, "Standup", "", 0, project.id)
+	upcoming.Recurrence = "daily"
+	upcoming.Due = time.Now().AddDate(0, 0, 1)
+	addTestTask(t, s, upcoming)
+
+	created, err := s.tasks.CatchUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 2 {
+		t.Fatalf("CatchUp made %d tasks, want 2", len(created))
+	}
+
+	want := map[string]string{
+		missed.Name:   today(),
+		finished.Name: time.Date(y, m+2, 1, 0, 0, 0, 0, time.Local).Format(dateFormat),
+	}
+
+	for _, task := range created {
+		if got := task.Due.Format(dateFormat); got != want[task.Name] {
+			t.Errorf("%s is due %s, want %s", task.Name, got, want[task.Name])
+		}
+
+		if task.Recurrence == "" {
+			t.Errorf("%s doesn't recur", task.Name)
+		}
+	}
+
+	// The series are caught up, so a second run has nothing to do
+	created, err = s.tasks.CatchUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 0 {
+		t.Errorf("CatchUp again made %d tasks, want none", len(created))
+	}
+}
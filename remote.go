@@ -0,0 +1,534 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long to wait on the kanban server before giving up
+const remoteTimeout = 10 * time.Second
+
+// A board on a kanban server, i.e. another kanban-cli running `serve`. It
+// speaks the API described in openapi.yaml.
+type RemoteStore struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewRemoteStore(url, token string) *RemoteStore {
+	return &RemoteStore{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: &http.Client{Timeout: remoteTimeout},
+	}
+}
+
+// Send a request to the server, with the body and response as JSON. Either
+// can be nil. 404, 409 and 422 responses come back as sql.ErrNoRows,
+// ErrConflict and ErrCycle, same as the local database.
+func (r *RemoteStore) do(method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, r.url+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return sql.ErrNoRows
+	case res.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case res.StatusCode == http.StatusUnprocessableEntity:
+		return ErrCycle
+	case res.StatusCode >= 300:
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(res.Body).Decode(&apiErr)
+
+		return fmt.Errorf("%s %s: %s %s", method, path, res.Status, apiErr.Error)
+	}
+
+	if result == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+func (r *RemoteStore) getTasks(path string) ([]Task, error) {
+	return r.doTasks(http.MethodGet, path)
+}
+
+// Send a request that responds with a list of tasks
+func (r *RemoteStore) doTasks(method, path string) ([]Task, error) {
+	var list []TaskJSON
+	if err := r.do(method, path, nil, &list); err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	for _, j := range list {
+		task, err := taskFromJSON(j)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// Send a request that responds with a task
+func (r *RemoteStore) doTask(method, path string, body any) (Task, error) {
+	var j TaskJSON
+	if err := r.do(method, path, body, &j); err != nil {
+		return Task{}, err
+	}
+
+	return taskFromJSON(j)
+}
+
+func (r *RemoteStore) GetTask(id int) (Task, error) {
+	return r.doTask(http.MethodGet, fmt.Sprintf("/api/tasks/%d", id), nil)
+}
+
+func (r *RemoteStore) GetTaskByKey(key string) (Task, error) {
+	return r.doTask(http.MethodGet, "/api/tasks/"+url.PathEscape(key), nil)
+}
+
+func (r *RemoteStore) GetTasksByStatus(status status, project int, filter TaskFilter) ([]Task, error) {
+	query := url.Values{}
+	query.Set("project", strconv.Itoa(project))
+	query.Set("status", status.String())
+	if filter.Assignee != 0 {
+		query.Set("assignee_id", strconv.Itoa(filter.Assignee))
+	}
+
+	if filter.Sprint != 0 {
+		query.Set("sprint_id", strconv.Itoa(filter.Sprint))
+	}
+
+	if filter.Epic != 0 {
+		query.Set("epic_id", strconv.Itoa(filter.Epic))
+	}
+
+	return r.getTasks("/api/tasks?" + query.Encode())
+}
+
+func (r *RemoteStore) GetAgenda() ([]Task, error) {
+	return r.getTasks("/api/agenda")
+}
+
+func (r *RemoteStore) InsertTask(task Task) (Task, error) {
+	return r.doTask(http.MethodPost, "/api/tasks", taskToJSON(task))
+}
+
+// Send every field Update writes, empty ones included, so they're cleared
+// on the server the same as in the local database
+func (r *RemoteStore) UpdateTask(task Task) error {
+	st := task.Status.String()
+	due := jsonDate(task.Due)
+	p := task.Priority.String()
+	tags := task.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	changes := taskChanges{
+		Name:       &task.Name,
+		Info:       &task.Info,
+		Status:     &st,
+		Due:        &due,
+		AssigneeId: &task.Assignee,
+		Priority:   &p,
+		Tags:       &tags,
+		Recurrence: &task.Recurrence,
+		Estimate:   &task.Estimate,
+		Version:    task.Version,
+	}
+
+	return r.do(http.MethodPatch, fmt.Sprintf("/api/tasks/%d", task.Id), changes, nil)
+}
+
+func (r *RemoteStore) NextStatus(task Task) (Task, error) {
+	changes := taskChanges{Version: task.Version}
+
+	return r.doTask(http.MethodPost, fmt.Sprintf("/api/tasks/%d/move", task.Id), changes)
+}
+
+func (r *RemoteStore) AssignTask(id int, user int) error {
+	changes := taskChanges{AssigneeId: &user}
+
+	return r.do(http.MethodPatch, fmt.Sprintf("/api/tasks/%d", id), changes, nil)
+}
+
+func (r *RemoteStore) UpdateTasks(ids []int, change BulkChange) error {
+	body := bulkChanges{
+		AssigneeId: change.Assignee,
+		SprintId:   change.Sprint,
+		Epic:       change.Epic,
+		Tag:        change.Tag,
+		Archive:    change.Archive,
+		Delete:     change.Delete,
+	}
+
+	for _, id := range ids {
+		body.Tasks = append(body.Tasks, strconv.Itoa(id))
+	}
+
+	if change.Status != nil {
+		st := change.Status.String()
+		body.Status = &st
+	}
+
+	if change.Priority != nil {
+		p := change.Priority.String()
+		body.Priority = &p
+	}
+
+	if change.ProjectId != 0 {
+		body.Project = strconv.Itoa(change.ProjectId)
+	}
+
+	// Zero takes the tasks out of their epic
+	if change.Parent != nil {
+		var parent string
+		if *change.Parent != 0 {
+			parent = strconv.Itoa(*change.Parent)
+		}
+
+		body.Parent = &parent
+	}
+
+	return r.do(http.MethodPost, "/api/tasks/bulk", body, nil)
+}
+
+func (r *RemoteStore) DeleteTask(id int) error {
+	return r.do(http.MethodDelete, fmt.Sprintf("/api/tasks/%d", id), nil, nil)
+}
+
+func (r *RemoteStore) GetBlockers(id int) ([]Task, error) {
+	return r.getTasks(fmt.Sprintf("/api/tasks/%d/blockers", id))
+}
+
+func (r *RemoteStore) GetBlocking(id int) ([]Task, error) {
+	return r.getTasks(fmt.Sprintf("/api/tasks/%d/blocking", id))
+}
+
+func (r *RemoteStore) AddBlocker(id int, blocker int) error {
+	body := map[string]string{"blocker": strconv.Itoa(blocker)}
+
+	return r.do(http.MethodPost, fmt.Sprintf("/api/tasks/%d/blockers", id), body, nil)
+}
+
+func (r *RemoteStore) RemoveBlocker(id int, blocker int) error {
+	return r.do(http.MethodDelete, fmt.Sprintf("/api/tasks/%d/blockers/%d", id, blocker), nil, nil)
+}
+
+func (r *RemoteStore) GetEpics() ([]Task, error) {
+	return r.getTasks("/api/epics")
+}
+
+func (r *RemoteStore) GetChildren(id int) ([]Task, error) {
+	return r.getTasks(fmt.Sprintf("/api/tasks/%d/children", id))
+}
+
+func (r *RemoteStore) RunRecurrences() ([]Task, error) {
+	return r.doTasks(http.MethodPost, "/api/recurrences/run")
+}
+
+func (r *RemoteStore) StartTimer(task int, user int) (TimeEntry, error) {
+	var j TimeEntryJSON
+	body := map[string]int{"user_id": user}
+	if err := r.do(http.MethodPost, fmt.Sprintf("/api/tasks/%d/timer", task), body, &j); err != nil {
+		return TimeEntry{}, err
+	}
+
+	return timeEntryFromJSON(j)
+}
+
+func (r *RemoteStore) StopTimer(user int) (TimeEntry, error) {
+	var j TimeEntryJSON
+	body := map[string]int{"user_id": user}
+	if err := r.do(http.MethodPost, "/api/timer/stop", body, &j); err != nil {
+		return TimeEntry{}, err
+	}
+
+	return timeEntryFromJSON(j)
+}
+
+func (r *RemoteStore) GetRunningTimer(user int) (TimeEntry, error) {
+	var j TimeEntryJSON
+	if err := r.do(http.MethodGet, fmt.Sprintf("/api/timer?user_id=%d", user), nil, &j); err != nil {
+		return TimeEntry{}, err
+	}
+
+	return timeEntryFromJSON(j)
+}
+
+func (r *RemoteStore) GetTimeEntries(task int) ([]TimeEntry, error) {
+	var list []TimeEntryJSON
+	if err := r.do(http.MethodGet, fmt.Sprintf("/api/tasks/%d/time", task), nil, &list); err != nil {
+		return nil, err
+	}
+
+	var entries []TimeEntry
+	for _, j := range list {
+		entry, err := timeEntryFromJSON(j)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (r *RemoteStore) GetTimeReport(since time.Time, user int) ([]TimeTotal, error) {
+	query := url.Values{}
+	query.Set("since", since.Format(dateFormat))
+	if user != 0 {
+		query.Set("user_id", strconv.Itoa(user))
+	}
+
+	var list []TimeTotalJSON
+	if err := r.do(http.MethodGet, "/api/time/report?"+query.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+
+	var totals []TimeTotal
+	for _, j := range list {
+		totals = append(totals, timeTotalFromJSON(j))
+	}
+
+	return totals, nil
+}
+
+func (r *RemoteStore) GetProject(id int) (Project, error) {
+	var j ProjectJSON
+	if err := r.do(http.MethodGet, fmt.Sprintf("/api/projects/%d", id), nil, &j); err != nil {
+		return Project{}, err
+	}
+
+	return projectFromJSON(j)
+}
+
+func (r *RemoteStore) GetProjectsByStatus(s projectStatus) ([]Project, error) {
+	query := "open"
+	if s == archived {
+		query = "archived"
+	}
+
+	var list []ProjectJSON
+	if err := r.do(http.MethodGet, "/api/projects?status="+query, nil, &list); err != nil {
+		return nil, err
+	}
+
+	var projects []Project
+	for _, j := range list {
+		project, err := projectFromJSON(j)
+		if err != nil {
+			return nil, err
+		}
+
+		projects = append(projects, project)
+	}
+
+	return projects, nil
+}
+
+func (r *RemoteStore) GetProjectStats(id int) (ProjectStats, error) {
+	var j ProjectStatsJSON
+	if err := r.do(http.MethodGet, fmt.Sprintf("/api/projects/%d/stats", id), nil, &j); err != nil {
+		return ProjectStats{}, err
+	}
+
+	return statsFromJSON(j)
+}
+
+func (r *RemoteStore) PrefixExists(prefix string) (bool, error) {
+	err := r.do(http.MethodGet, "/api/projects/"+url.PathEscape(prefix), nil, nil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (r *RemoteStore) InsertProject(project Project, tasks []Task) (Project, error) {
+	body := NewProjectJSON{ProjectJSON: projectToJSON(project)}
+	for _, task := range tasks {
+		body.Tasks = append(body.Tasks, taskToJSON(task))
+	}
+
+	var created ProjectJSON
+	err := r.do(http.MethodPost, "/api/projects", body, &created)
+
+	// The only conflict a new project can have
+	if errors.Is(err, ErrConflict) {
+		return project, ErrPrefixTaken
+	}
+
+	if err != nil {
+		return project, err
+	}
+
+	return projectFromJSON(created)
+}
+
+func (r *RemoteStore) UpdateProject(project Project) error {
+	j := projectToJSON(project)
+
+	// Null would leave the lanes alone, rather than go back to the usual ones
+	if j.Lanes == nil {
+		j.Lanes = []string{}
+	}
+
+	changes := projectChanges{
+		Name:        &j.Name,
+		Description: &j.Description,
+		Color:       &j.Color,
+		Owner:       &j.Owner,
+		TargetDate:  &j.TargetDate,
+		Lanes:       &j.Lanes,
+	}
+
+	return r.do(http.MethodPatch, fmt.Sprintf("/api/projects/%d", project.id), changes, nil)
+}
+
+func (r *RemoteStore) RenameProject(id int, name string) error {
+	changes := projectChanges{Name: &name}
+
+	return r.do(http.MethodPatch, fmt.Sprintf("/api/projects/%d", id), changes, nil)
+}
+
+func (r *RemoteStore) MoveProject(id int, delta int) error {
+	body := map[string]int{"delta": delta}
+
+	return r.do(http.MethodPost, fmt.Sprintf("/api/projects/%d/move", id), body, nil)
+}
+
+func (r *RemoteStore) ArchiveProject(id int) error {
+	return r.do(http.MethodPost, fmt.Sprintf("/api/projects/%d/archive", id), nil, nil)
+}
+
+func (r *RemoteStore) UnarchiveProject(id int) error {
+	return r.do(http.MethodPost, fmt.Sprintf("/api/projects/%d/unarchive", id), nil, nil)
+}
+
+func (r *RemoteStore) DeleteProject(id int) error {
+	return r.do(http.MethodDelete, fmt.Sprintf("/api/projects/%d", id), nil, nil)
+}
+
+func (r *RemoteStore) GetSprints(project int) ([]Sprint, error) {
+	var list []SprintJSON
+	if err := r.do(http.MethodGet, fmt.Sprintf("/api/projects/%d/sprints", project), nil, &list); err != nil {
+		return nil, err
+	}
+
+	var sprints []Sprint
+	for _, j := range list {
+		sprint, err := sprintFromJSON(j)
+		if err != nil {
+			return nil, err
+		}
+
+		sprints = append(sprints, sprint)
+	}
+
+	return sprints, nil
+}
+
+func (r *RemoteStore) InsertSprint(sprint Sprint) (Sprint, error) {
+	var j SprintJSON
+	path := fmt.Sprintf("/api/projects/%d/sprints", sprint.ProjectId)
+	if err := r.do(http.MethodPost, path, sprintToJSON(sprint), &j); err != nil {
+		return Sprint{}, err
+	}
+
+	return sprintFromJSON(j)
+}
+
+func (r *RemoteStore) CloseSprint(id int) (Sprint, error) {
+	var j SprintJSON
+	if err := r.do(http.MethodPost, fmt.Sprintf("/api/sprints/%d/close", id), nil, &j); err != nil {
+		return Sprint{}, err
+	}
+
+	return sprintFromJSON(j)
+}
+
+func (r *RemoteStore) GetTemplates() (Templates, error) {
+	var templates Templates
+	err := r.do(http.MethodGet, "/api/templates", nil, &templates)
+
+	return templates, err
+}
+
+func (r *RemoteStore) SaveTaskTemplate(template TaskTemplate) error {
+	return r.do(http.MethodPut, "/api/templates/task/"+url.PathEscape(template.Name), template, nil)
+}
+
+func (r *RemoteStore) SaveProjectTemplate(template ProjectTemplate) error {
+	return r.do(http.MethodPut, "/api/templates/project/"+url.PathEscape(template.Name), template, nil)
+}
+
+func (r *RemoteStore) DeleteTemplate(kind, name string) error {
+	return r.do(http.MethodDelete, "/api/templates/"+kind+"/"+url.PathEscape(name), nil, nil)
+}
+
+func (r *RemoteStore) FindUser(name string) (User, error) {
+	var j UserJSON
+	err := r.do(http.MethodPost, "/api/users/find", UserJSON{Name: name}, &j)
+
+	return userFromJSON(j), err
+}
+
+func (r *RemoteStore) EnsureUser(name, displayName string) (User, error) {
+	var j UserJSON
+	err := r.do(http.MethodPost, "/api/users", UserJSON{Name: name, DisplayName: displayName}, &j)
+
+	return userFromJSON(j), err
+}
+
+func (r *RemoteStore) Revision() (int, error) {
+	var j struct {
+		Revision int `json:"revision"`
+	}
+	err := r.do(http.MethodGet, "/api/revision", nil, &j)
+
+	return j.Revision, err
+}
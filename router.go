@@ -0,0 +1,146 @@
+package main
+
+import (
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var errorDialogStyle = confirmStyle.Copy().
+	BorderForeground(lipgloss.Color("9"))
+
+// The root model. It keeps a stack of screens and shows the one on top,
+// which gets every message apart from the size of the terminal, which all of
+// them get. Screens don't know about each other: they move between
+// themselves with the Push, Pop and Replace commands.
+type Router struct {
+	stack  []tea.Model
+	err    error // Shown over the current screen until a key is pressed
+	width  int
+	height int
+}
+
+// Sent when something the user asked for couldn't be done, e.g. the db or
+// the server didn't answer. The router shows it, and the screen carries on
+// as it was, rather than the whole program (or every SSH session) going
+// down with it.
+type ErrorMsg struct {
+	err error
+}
+
+// Report the error to the user
+func reportError(err error) tea.Cmd {
+	return func() tea.Msg {
+		return ErrorMsg{err: err}
+	}
+}
+
+type pushMsg struct {
+	model tea.Model
+}
+
+type popMsg struct{}
+
+type replaceMsg struct {
+	model tea.Model
+}
+
+// Open a screen on top of the current one, which comes back when it's popped
+func Push(model tea.Model) tea.Cmd {
+	return func() tea.Msg {
+		return pushMsg{model: model}
+	}
+}
+
+// Close the current screen, going back to the one under it. Closing the
+// last screen quits.
+func Pop() tea.Cmd {
+	return func() tea.Msg {
+		return popMsg{}
+	}
+}
+
+// Swap the current screen for another, so going back skips it
+func Replace(model tea.Model) tea.Cmd {
+	return func() tea.Msg {
+		return replaceMsg{model: model}
+	}
+}
+
+func NewRouter(root tea.Model) *Router {
+	return &Router{stack: []tea.Model{root}}
+}
+
+func (r *Router) top() tea.Model {
+	return r.stack[len(r.stack)-1]
+}
+
+func (r *Router) Init() tea.Cmd {
+	return r.top().Init()
+}
+
+func (r *Router) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ErrorMsg:
+		log.Println(msg.err)
+		r.err = msg.err
+		return r, nil
+	case tea.KeyMsg:
+		if r.err != nil {
+			// Any key dismisses the error
+			r.err = nil
+			if msg.String() == "ctrl+c" {
+				return r, tea.Quit
+			}
+
+			return r, nil
+		}
+	case tea.WindowSizeMsg:
+		r.width = msg.Width
+		r.height = msg.Height
+
+		// Screens further down need it too, or they come back at the
+		// old size
+		var cmds []tea.Cmd
+		for i, model := range r.stack {
+			var cmd tea.Cmd
+			r.stack[i], cmd = model.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+
+		return r, tea.Batch(cmds...)
+	case pushMsg:
+		r.stack = append(r.stack, msg.model)
+		return r, msg.model.Init()
+	case replaceMsg:
+		r.stack[len(r.stack)-1] = msg.model
+		return r, msg.model.Init()
+	case popMsg:
+		if len(r.stack) == 1 {
+			return r, tea.Quit
+		}
+
+		r.stack = r.stack[:len(r.stack)-1]
+		return r, nil
+	}
+
+	var cmd tea.Cmd
+	r.stack[len(r.stack)-1], cmd = r.top().Update(msg)
+
+	return r, cmd
+}
+
+func (r *Router) View() string {
+	view := r.top().View()
+	if r.err == nil {
+		return view
+	}
+
+	dialog := errorDialogStyle.Render("Something went wrong:\n" + r.err.Error() + "\n\n(press any key)")
+	if r.width == 0 || r.height == 0 {
+		return dialog
+	}
+
+	return overlay(view, dialog, r.width, r.height)
+}
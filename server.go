@@ -0,0 +1,1486 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultAddr = "127.0.0.1:7575"
+
+// The API description, served at /api/openapi.yaml
+//
+//go:embed openapi.yaml
+var openAPI []byte
+
+// An HTTP/JSON API over the tasks and projects, for editor plugins,
+// dashboards and bots. Every request but the OpenAPI document needs the
+// api_token from the config file as a bearer token.
+type Server struct {
+	local     *SQLiteStore
+	tasks     *TaskDB
+	projects  *ProjectDB
+	users     *UserDB
+	templates *TemplateDB
+	user      User // Whoever runs the server
+	token     string
+	mux       *http.ServeMux
+}
+
+func NewServer(local *SQLiteStore, token string, user User) *Server {
+	s := &Server{
+		local:     local,
+		tasks:     local.tasks,
+		projects:  local.projects,
+		users:     local.users,
+		templates: local.templates,
+		user:      user,
+		token:     token,
+		mux:       http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /api/openapi.yaml", s.getOpenAPI)
+	s.mux.HandleFunc("GET /api/revision", s.getRevision)
+	s.mux.HandleFunc("GET /api/projects", s.listProjects)
+	s.mux.HandleFunc("POST /api/projects", s.createProject)
+	s.mux.HandleFunc("GET /api/projects/{ref}", s.getProject)
+	s.mux.HandleFunc("PATCH /api/projects/{ref}", s.updateProject)
+	s.mux.HandleFunc("DELETE /api/projects/{ref}", s.deleteProject)
+	s.mux.HandleFunc("GET /api/projects/{ref}/stats", s.getProjectStats)
+	s.mux.HandleFunc("POST /api/projects/{ref}/archive", s.archiveProject)
+	s.mux.HandleFunc("POST /api/projects/{ref}/unarchive", s.unarchiveProject)
+	s.mux.HandleFunc("POST /api/projects/{ref}/move", s.moveProject)
+	s.mux.HandleFunc("GET /api/projects/{ref}/sprints", s.listSprints)
+	s.mux.HandleFunc("POST /api/projects/{ref}/sprints", s.createSprint)
+	s.mux.HandleFunc("POST /api/sprints/{id}/close", s.closeSprint)
+	s.mux.HandleFunc("GET /api/agenda", s.getAgenda)
+	s.mux.HandleFunc("GET /api/epics", s.getEpics)
+	s.mux.HandleFunc("GET /api/tasks", s.listTasks)
+	s.mux.HandleFunc("POST /api/tasks", s.createTask)
+	s.mux.HandleFunc("POST /api/tasks/bulk", s.updateTasks)
+	s.mux.HandleFunc("GET /api/tasks/{ref}", s.getTask)
+	s.mux.HandleFunc("PATCH /api/tasks/{ref}", s.updateTask)
+	s.mux.HandleFunc("POST /api/tasks/{ref}/move", s.moveTask)
+	s.mux.HandleFunc("DELETE /api/tasks/{ref}", s.deleteTask)
+	s.mux.HandleFunc("GET /api/tasks/{ref}/blockers", s.listBlockers)
+	s.mux.HandleFunc("POST /api/tasks/{ref}/blockers", s.addBlocker)
+	s.mux.HandleFunc("DELETE /api/tasks/{ref}/blockers/{blocker}", s.removeBlocker)
+	s.mux.HandleFunc("GET /api/tasks/{ref}/blocking", s.listBlocking)
+	s.mux.HandleFunc("GET /api/tasks/{ref}/children", s.listChildren)
+	s.mux.HandleFunc("POST /api/recurrences/run", s.runRecurrences)
+	s.mux.HandleFunc("GET /api/tasks/{ref}/time", s.listTimeEntries)
+	s.mux.HandleFunc("POST /api/tasks/{ref}/timer", s.startTimer)
+	s.mux.HandleFunc("GET /api/timer", s.getTimer)
+	s.mux.HandleFunc("POST /api/timer/stop", s.stopTimer)
+	s.mux.HandleFunc("GET /api/time/report", s.getTimeReport)
+	s.mux.HandleFunc("GET /api/templates", s.listTemplates)
+	s.mux.HandleFunc("PUT /api/templates/{kind}/{name}", s.saveTemplate)
+	s.mux.HandleFunc("DELETE /api/templates/{kind}/{name}", s.deleteTemplate)
+	s.mux.HandleFunc("POST /api/users", s.ensureUser)
+	s.mux.HandleFunc("POST /api/users/find", s.findUser)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/openapi.yaml" && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, errors.New("missing or wrong api token"))
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// Run the API server until it fails, for `kanban-cli serve`
+func serve(args []string, user User) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", defaultAddr, "address to listen on")
+	flags.Parse(args)
+
+	if config.APIToken == "" {
+		return fmt.Errorf("set api_token in %s to serve the API", getConfigPath())
+	}
+
+	local, ok := store.(*SQLiteStore)
+	if !ok {
+		return errors.New("only the local database can be served, drop --remote")
+	}
+
+	// Log requests to the terminal rather than the debug log
+	log.SetOutput(os.Stderr)
+
+	log.Printf("Serving the API on http://%s/api", *addr)
+
+	return http.ListenAndServe(*addr, logRequests(NewServer(local, config.APIToken, user)))
+}
+
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Println(r.Method, r.URL)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// Write the error with the status code that fits it best
+func writeDBError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	case errors.Is(err, ErrConflict), errors.Is(err, ErrPrefixTaken):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, ErrCycle):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, ErrEpicParent):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		log.Println(err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+	}
+}
+
+func (s *Server) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPI)
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	var list []Project
+	var err error
+
+	switch r.URL.Query().Get("status") {
+	case "", "open":
+		list, err = s.projects.GetByStatus(open)
+	case "archived":
+		list, err = s.projects.GetByStatus(archived)
+	case "all":
+		list, err = s.projects.GetAll()
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("status must be open, archived or all"))
+		return
+	}
+
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []ProjectJSON{}
+	for _, p := range list {
+		result = append(result, projectToJSON(p))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	project, err := s.projects.Resolve(r.PathValue("ref"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, projectToJSON(project))
+}
+
+func (s *Server) archiveProject(w http.ResponseWriter, r *http.Request) {
+	s.changeProject(w, r, s.projects.ArchiveProject)
+}
+
+func (s *Server) unarchiveProject(w http.ResponseWriter, r *http.Request) {
+	s.changeProject(w, r, s.projects.UnarchiveProject)
+}
+
+// Make a change to the project in the path, and respond with the project
+func (s *Server) changeProject(w http.ResponseWriter, r *http.Request, change func(int) error) {
+	project, err := s.projects.Resolve(r.PathValue("ref"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if err = change(project.id); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	s.getProject(w, r)
+}
+
+// List tasks, optionally narrowed down by project, status, assignee,
+// sprint and epic. An epic_id gives the epic and the tasks in it.
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var projectId int
+	if ref := query.Get("project"); ref != "" {
+		project, err := s.projects.Resolve(ref)
+		if err != nil {
+			writeDBError(w, err)
+			return
+		}
+
+		projectId = project.id
+	}
+
+	statusFilter := -1
+	if st := query.Get("status"); st != "" {
+		var err error
+		if statusFilter, err = GetStatusFromString(st); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	assigneeId, _ := strconv.Atoi(query.Get("assignee_id"))
+	if name := query.Get("assignee"); name != "" {
+		user, ok := s.findUserNamed(w, name)
+		if !ok {
+			return
+		}
+
+		assigneeId = user.id
+	}
+
+	filter := TaskFilter{Assignee: assigneeId}
+	filter.Sprint, _ = strconv.Atoi(query.Get("sprint_id"))
+	filter.Epic, _ = strconv.Atoi(query.Get("epic_id"))
+
+	// Archived tasks are left out, unless they're what's asked for
+	archived := query.Get("archived") == "true"
+
+	list, err := s.tasks.List(projectId, statusFilter, archived, filter)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []TaskJSON{}
+	for _, t := range list {
+		result = append(result, taskToJSON(t))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Look up the task by key or id, writing an error response if there's no
+// such task
+func (s *Server) resolveTask(w http.ResponseWriter, ref string) (Task, bool) {
+	if _, err := strconv.Atoi(ref); err != nil {
+		if _, _, err := ParseTaskKey(ref); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return Task{}, false
+		}
+	}
+
+	task, err := s.tasks.Resolve(ref)
+	if err != nil {
+		writeDBError(w, err)
+		return task, false
+	}
+
+	return task, true
+}
+
+// Look up a user by name, for assigning a task to them or filtering on them.
+// Nobody is added: a name nobody has is a bad request.
+func (s *Server) findUserNamed(w http.ResponseWriter, name string) (User, bool) {
+	user, err := s.users.Find(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown user %s", name))
+		return user, false
+	}
+
+	if err != nil {
+		writeDBError(w, err)
+		return user, false
+	}
+
+	return user, true
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, taskToJSON(task))
+}
+
+// The body of a new task. The project is given by id or by prefix, and
+// the epic it's part of by id or by key.
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	var body TaskJSON
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if strings.TrimSpace(body.Name) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+
+	ref := body.Project
+	if body.ProjectId != 0 {
+		ref = fmt.Sprint(body.ProjectId)
+	}
+
+	if ref == "" {
+		writeError(w, http.StatusBadRequest, errors.New("project or project_id is required"))
+		return
+	}
+
+	project, err := s.projects.Resolve(ref)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	task := Task{
+		Name:      body.Name,
+		Info:      body.Info,
+		ProjectId: project.id,
+		Assignee:  body.AssigneeId,
+		CreatedBy: body.CreatedById,
+	}
+
+	// Tasks created through the API without a creator are on whoever
+	// runs the server
+	if task.CreatedBy == 0 {
+		task.CreatedBy = s.user.id
+	} else if !s.checkUser(w, task.CreatedBy) {
+		return
+	}
+
+	if task.Assignee != 0 && !s.checkUser(w, task.Assignee) {
+		return
+	}
+
+	if body.Status != "" {
+		st, err := GetStatusFromString(body.Status)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		task.Status = status(st)
+	}
+
+	if body.Priority != "" {
+		if task.Priority, err = GetPriorityFromString(body.Priority); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	task.Tags = parseTags(formatTags(body.Tags))
+
+	if body.Recurrence != "" {
+		r, err := ParseRecurrence(body.Recurrence)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		task.Recurrence = r.String()
+	}
+
+	if body.Estimate < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("estimate can't be negative"))
+		return
+	}
+	task.Estimate = body.Estimate
+
+	if body.SprintId != 0 && !s.checkSprint(w, body.SprintId, project.id) {
+		return
+	}
+	task.SprintId = body.SprintId
+
+	task.Epic = body.Epic
+	task.ParentId = body.ParentId
+	if body.Parent != "" {
+		parent, ok := s.resolveTask(w, body.Parent)
+		if !ok {
+			return
+		}
+
+		task.ParentId = parent.Id
+	}
+
+	if task.Due, err = parseDate(body.Due); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("due must be YYYY-MM-DD"))
+		return
+	}
+
+	if body.Assignee != "" {
+		user, ok := s.findUserNamed(w, body.Assignee)
+		if !ok {
+			return
+		}
+
+		task.Assignee = user.id
+	}
+
+	created, err := s.local.InsertTask(task)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, taskToJSON(created))
+}
+
+// Changes to a task. Fields left out stay as they are; empty info, due and
+// assignee fields (or a zero assignee id) clear them, and so do an empty
+// recurrence, a zero estimate, a zero sprint id and an empty parent. Tags
+// replace the task's tags. The parent is the key or id of an epic. If a
+// version is given and the task has moved on since, nothing changes and the
+// response is 409 Conflict.
+type taskChanges struct {
+	Name       *string   `json:"name"`
+	Info       *string   `json:"info"`
+	Status     *string   `json:"status"`
+	Due        *string   `json:"due"`
+	Assignee   *string   `json:"assignee"`
+	AssigneeId *int      `json:"assignee_id"`
+	Priority   *string   `json:"priority"`
+	Tags       *[]string `json:"tags"`
+	Recurrence *string   `json:"recurrence"`
+	Estimate   *float64  `json:"estimate"`
+	SprintId   *int      `json:"sprint_id"`
+	Epic       *bool     `json:"epic"`
+	Parent     *string   `json:"parent"`
+	Version    int       `json:"version"`
+}
+
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	var changes taskChanges
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !s.applyChanges(w, task, changes) {
+		return
+	}
+
+	s.getTask(w, r)
+}
+
+// Apply the changes to the task. If they can't be applied, an error response
+// is written and false is returned. Everything changes in one transaction,
+// or nothing does.
+func (s *Server) applyChanges(w http.ResponseWriter, task Task, changes taskChanges) bool {
+	// The task as it was just read, with the changes on top. Without a
+	// version from the client, it's checked against the one just read.
+	update := task
+	if changes.Version != 0 {
+		update.Version = changes.Version
+	}
+
+	if changes.Name != nil {
+		if strings.TrimSpace(*changes.Name) == "" {
+			writeError(w, http.StatusBadRequest, errors.New("name can't be empty"))
+			return false
+		}
+
+		update.Name = *changes.Name
+	}
+
+	if changes.Info != nil {
+		update.Info = *changes.Info
+	}
+
+	if changes.Status != nil {
+		st, err := GetStatusFromString(*changes.Status)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return false
+		}
+
+		update.Status = status(st)
+	}
+
+	if changes.Due != nil {
+		due, err := parseDate(*changes.Due)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("due must be YYYY-MM-DD"))
+			return false
+		}
+
+		update.Due = due
+	}
+
+	if changes.Priority != nil {
+		p, err := GetPriorityFromString(*changes.Priority)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return false
+		}
+
+		update.Priority = p
+	}
+
+	if changes.Tags != nil {
+		update.Tags = parseTags(formatTags(*changes.Tags))
+	}
+
+	if changes.Recurrence != nil {
+		update.Recurrence = ""
+		if *changes.Recurrence != "" {
+			r, err := ParseRecurrence(*changes.Recurrence)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return false
+			}
+
+			update.Recurrence = r.String()
+		}
+	}
+
+	if changes.Estimate != nil {
+		if *changes.Estimate < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("estimate can't be negative"))
+			return false
+		}
+
+		update.Estimate = *changes.Estimate
+	}
+
+	// The assignee can be given by id or by name
+	switch {
+	case changes.AssigneeId != nil:
+		if *changes.AssigneeId != 0 && !s.checkUser(w, *changes.AssigneeId) {
+			return false
+		}
+
+		update.Assignee = *changes.AssigneeId
+	case changes.Assignee != nil && *changes.Assignee != "":
+		user, ok := s.findUserNamed(w, *changes.Assignee)
+		if !ok {
+			return false
+		}
+
+		update.Assignee = user.id
+	case changes.Assignee != nil:
+		update.Assignee = 0
+	}
+
+	// The sprint and epic have rules of their own, see updateInTx
+	change := BulkChange{Sprint: changes.SprintId, Epic: changes.Epic}
+
+	if changes.SprintId != nil && *changes.SprintId != 0 && !s.checkSprint(w, *changes.SprintId, task.ProjectId) {
+		return false
+	}
+
+	if changes.Parent != nil {
+		var parent int
+		if *changes.Parent != "" {
+			epic, ok := s.resolveTask(w, *changes.Parent)
+			if !ok {
+				return false
+			}
+
+			parent = epic.Id
+		}
+
+		change.Parent = &parent
+	}
+
+	if err := s.tasks.UpdateWith(update, change); err != nil {
+		writeDBError(w, err)
+		return false
+	}
+
+	return true
+}
+
+// Move a task to the given status, or on to the next one if there's no body
+func (s *Server) moveTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	var changes taskChanges
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// On to the next status, same as on the board, which brings round
+	// the next instance of a recurring task
+	if changes.Status == nil {
+		task.Version = changes.Version
+		if _, err := s.tasks.NextStatus(task); err != nil {
+			writeDBError(w, err)
+			return
+		}
+
+		s.getTask(w, r)
+		return
+	}
+
+	// Only the status moves
+	changes = taskChanges{Status: changes.Status, Version: changes.Version}
+	if !s.applyChanges(w, task, changes) {
+		return
+	}
+
+	s.getTask(w, r)
+}
+
+// A change to many tasks at once, see TaskDB.UpdateMany. The tasks are
+// given by key or id, and the project to move them to by prefix or id.
+type bulkChanges struct {
+	Tasks      []string `json:"tasks"`
+	Status     *string  `json:"status"`
+	Priority   *string  `json:"priority"`
+	AssigneeId *int     `json:"assignee_id"`
+	SprintId   *int     `json:"sprint_id"`
+	Epic       *bool    `json:"epic"`
+	Parent     *string  `json:"parent"` // Key or id of an epic, empty for none
+	Tag        string   `json:"tag"`
+	Project    string   `json:"project"`
+	Archive    bool     `json:"archive"`
+	Delete     bool     `json:"delete"`
+}
+
+func (s *Server) updateTasks(w http.ResponseWriter, r *http.Request) {
+	var body bulkChanges
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var ids []int
+	for _, ref := range body.Tasks {
+		task, ok := s.resolveTask(w, ref)
+		if !ok {
+			return
+		}
+
+		ids = append(ids, task.Id)
+	}
+
+	if body.AssigneeId != nil && *body.AssigneeId != 0 && !s.checkUser(w, *body.AssigneeId) {
+		return
+	}
+
+	change := BulkChange{
+		Assignee: body.AssigneeId,
+		Sprint:   body.SprintId,
+		Epic:     body.Epic,
+		Tag:      strings.TrimSpace(body.Tag),
+		Archive:  body.Archive,
+		Delete:   body.Delete,
+	}
+
+	if strings.Contains(change.Tag, ",") {
+		writeError(w, http.StatusBadRequest, errors.New("tag can't have a comma in it"))
+		return
+	}
+
+	if body.Parent != nil {
+		var parent int
+		if *body.Parent != "" {
+			epic, ok := s.resolveTask(w, *body.Parent)
+			if !ok {
+				return
+			}
+
+			parent = epic.Id
+		}
+
+		change.Parent = &parent
+	}
+
+	if body.Status != nil {
+		n, err := GetStatusFromString(*body.Status)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		st := status(n)
+		change.Status = &st
+	}
+
+	if body.Priority != nil {
+		p, err := GetPriorityFromString(*body.Priority)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		change.Priority = &p
+	}
+
+	if body.Project != "" {
+		project, err := s.projects.Resolve(body.Project)
+		if err != nil {
+			writeDBError(w, err)
+			return
+		}
+
+		change.ProjectId = project.id
+	}
+
+	if err := s.tasks.UpdateMany(ids, change); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	if err := s.tasks.Delete(task.Id); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listBlockers(w http.ResponseWriter, r *http.Request) {
+	s.listLinked(w, r, s.tasks.GetBlockers)
+}
+
+func (s *Server) listBlocking(w http.ResponseWriter, r *http.Request) {
+	s.listLinked(w, r, s.tasks.GetBlocking)
+}
+
+func (s *Server) listChildren(w http.ResponseWriter, r *http.Request) {
+	s.listLinked(w, r, s.tasks.GetChildren)
+}
+
+// Respond with the tasks linked to the one in the path
+func (s *Server) listLinked(w http.ResponseWriter, r *http.Request, get func(int) ([]Task, error)) {
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	list, err := get(task.Id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []TaskJSON{}
+	for _, t := range list {
+		result = append(result, taskToJSON(t))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// The body names the blocker by id or key. Responds with the blockers.
+func (s *Server) addBlocker(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Blocker string `json:"blocker"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	blocker, ok := s.resolveTask(w, body.Blocker)
+	if !ok {
+		return
+	}
+
+	if err := s.tasks.AddBlocker(task.Id, blocker.Id); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	s.listBlockers(w, r)
+}
+
+func (s *Server) removeBlocker(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	blocker, ok := s.resolveTask(w, r.PathValue("blocker"))
+	if !ok {
+		return
+	}
+
+	if err := s.tasks.RemoveBlocker(task.Id, blocker.Id); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Catch up on recurring tasks, see TaskDB.CatchUp
+func (s *Server) runRecurrences(w http.ResponseWriter, r *http.Request) {
+	list, err := s.tasks.CatchUp()
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []TaskJSON{}
+	for _, t := range list {
+		result = append(result, taskToJSON(t))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) listTimeEntries(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	entries, err := s.tasks.GetTimeEntries(task.Id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []TimeEntryJSON{}
+	for _, e := range entries {
+		result = append(result, timeEntryToJSON(e))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Timers are per user, given by id in the body or query. Without one
+// they're whoever runs the server's.
+func (s *Server) timerUser(id int) int {
+	if id == 0 {
+		return s.user.id
+	}
+
+	return id
+}
+
+func (s *Server) startTimer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		UserId int `json:"user_id"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	task, ok := s.resolveTask(w, r.PathValue("ref"))
+	if !ok {
+		return
+	}
+
+	entry, err := s.tasks.StartTimer(task.Id, s.timerUser(body.UserId))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, timeEntryToJSON(entry))
+}
+
+// 404 if the user has no timer running
+func (s *Server) getTimer(w http.ResponseWriter, r *http.Request) {
+	var user int
+	if id := r.URL.Query().Get("user_id"); id != "" {
+		var err error
+		if user, err = strconv.Atoi(id); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entry, err := s.tasks.RunningTimer(s.timerUser(user))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, timeEntryToJSON(entry))
+}
+
+func (s *Server) stopTimer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		UserId int `json:"user_id"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entry, err := s.tasks.StopTimer(s.timerUser(body.UserId))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, timeEntryToJSON(entry))
+}
+
+// Everyone's time unless user_id is given. since is a date, and defaults to
+// the start of the week.
+func (s *Server) getTimeReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	since := startOfWeek()
+	if query.Get("since") != "" {
+		var err error
+		if since, err = parseDate(query.Get("since")); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("since must look like %s", dateFormat))
+			return
+		}
+	}
+
+	var user int
+	if id := query.Get("user_id"); id != "" {
+		var err error
+		if user, err = strconv.Atoi(id); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	totals, err := s.tasks.TimeReport(since, user)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []TimeTotalJSON{}
+	for _, t := range totals {
+		result = append(result, timeTotalToJSON(t))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) listSprints(w http.ResponseWriter, r *http.Request) {
+	project, err := s.projects.Resolve(r.PathValue("ref"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	sprints, err := s.projects.GetSprints(project.id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []SprintJSON{}
+	for _, sprint := range sprints {
+		result = append(result, sprintToJSON(sprint))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Start a sprint in the project in the path. Its start and end dates are
+// required.
+func (s *Server) createSprint(w http.ResponseWriter, r *http.Request) {
+	project, err := s.projects.Resolve(r.PathValue("ref"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	var body SprintJSON
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sprint := Sprint{ProjectId: project.id, Name: strings.TrimSpace(body.Name)}
+
+	sprint.Start, err = parseDate(body.Start)
+	if err == nil {
+		sprint.End, err = parseDate(body.End)
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("start and end must be YYYY-MM-DD"))
+		return
+	}
+
+	if err := sprint.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	created, err := s.projects.InsertSprint(sprint)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, sprintToJSON(created))
+}
+
+// Close the sprint, carrying unfinished tasks on, and respond with its
+// summary
+func (s *Server) closeSprint(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("sprint id must be a number"))
+		return
+	}
+
+	sprint, err := s.projects.CloseSprint(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sprintToJSON(sprint))
+}
+
+// Check that there's a user with the id, writing a 400 response if there
+// isn't. Nothing else stops a task pointing at a user who doesn't exist.
+func (s *Server) checkUser(w http.ResponseWriter, id int) bool {
+	_, err := s.users.Get(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown user id %d", id))
+		return false
+	}
+
+	if err != nil {
+		writeDBError(w, err)
+		return false
+	}
+
+	return true
+}
+
+// Tasks can only be planned into open sprints of their own project. If the
+// sprint isn't one, a 404 response is written and false is returned.
+func (s *Server) checkSprint(w http.ResponseWriter, id int, project int) bool {
+	sprint, err := s.projects.GetSprint(id)
+	if err == nil && (sprint.ProjectId != project || !sprint.Open()) {
+		err = sql.ErrNoRows
+	}
+
+	if err != nil {
+		writeDBError(w, err)
+		return false
+	}
+
+	return true
+}
+
+// The saved templates. Template files on the server aren't shared, only
+// what's in its database.
+func (s *Server) listTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.templates.GetAll()
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if templates.Tasks == nil {
+		templates.Tasks = []TaskTemplate{}
+	}
+
+	if templates.Projects == nil {
+		templates.Projects = []ProjectTemplate{}
+	}
+
+	writeJSON(w, http.StatusOK, templates)
+}
+
+// Save a task or project template under the name in the path
+func (s *Server) saveTemplate(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	name := r.PathValue("name")
+
+	var template interface{ validate() error }
+	switch kind {
+	case taskTemplateKind:
+		var t TaskTemplate
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		t.Name = name
+		template = t
+	case projectTemplateKind:
+		var t ProjectTemplate
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		t.Name = name
+		template = t
+	default:
+		writeError(w, http.StatusNotFound, errors.New("kind must be task or project"))
+		return
+	}
+
+	if err := template.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.templates.Save(kind, name, template); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, template)
+}
+
+func (s *Server) deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := s.templates.Delete(r.PathValue("kind"), r.PathValue("name")); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getRevision(w http.ResponseWriter, r *http.Request) {
+	revision, err := s.local.Revision()
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"revision": revision})
+}
+
+// Tasks that need attention today, see TaskDB.GetAgenda
+func (s *Server) getAgenda(w http.ResponseWriter, r *http.Request) {
+	list, err := s.tasks.GetAgenda()
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []TaskJSON{}
+	for _, t := range list {
+		result = append(result, taskToJSON(t))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Epics in open projects, see TaskDB.GetEpics
+func (s *Server) getEpics(w http.ResponseWriter, r *http.Request) {
+	list, err := s.tasks.GetEpics()
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result := []TaskJSON{}
+	for _, t := range list {
+		result = append(result, taskToJSON(t))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) getProjectStats(w http.ResponseWriter, r *http.Request) {
+	project, err := s.projects.Resolve(r.PathValue("ref"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	stats, err := s.local.GetProjectStats(project.id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statsToJSON(stats))
+}
+
+// The body of a new project. Without a prefix, one is made from the name.
+// Any tasks in it are added to the project's todo lane along with it.
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+	var body NewProjectJSON
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := parseLanes(formatLanes(body.Lanes)); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var tasks []Task
+	for _, t := range body.Tasks {
+		if strings.TrimSpace(t.Name) == "" {
+			writeError(w, http.StatusBadRequest, errors.New("every task needs a name"))
+			return
+		}
+
+		task := NewTask(todo, t.Name, t.Info, 0, 0)
+		task.Tags = parseTags(formatTags(t.Tags))
+		task.CreatedBy = s.user.id
+		tasks = append(tasks, task)
+	}
+
+	project, err := projectFromJSON(body.ProjectJSON)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("target_date must be YYYY-MM-DD"))
+		return
+	}
+
+	if strings.TrimSpace(project.name) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+
+	if project.color != "" && !ValidColor(project.color) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid color %s", project.color))
+		return
+	}
+
+	if project.prefix == "" {
+		project.prefix = DefaultPrefix(project.name)
+	}
+
+	project.prefix = strings.ToUpper(project.prefix)
+	if !ValidPrefix(project.prefix) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid prefix %s", project.prefix))
+		return
+	}
+
+	taken, err := s.projects.PrefixExists(project.prefix)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if taken {
+		writeError(w, http.StatusConflict, fmt.Errorf("prefix %s is taken", project.prefix))
+		return
+	}
+
+	// New projects are always open
+	project.status = open
+
+	id, err := s.projects.Insert(project, tasks)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	created, err := s.projects.Get(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, projectToJSON(created))
+}
+
+// Changes to a project. Fields left out stay as they are. The prefix can't
+// be changed, see ProjectDB.Update.
+type projectChanges struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Color       *string   `json:"color"`
+	Owner       *string   `json:"owner"`
+	TargetDate  *string   `json:"target_date"`
+	Lanes       *[]string `json:"lanes"` // Empty for the usual titles
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	project, err := s.projects.Resolve(r.PathValue("ref"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	var changes projectChanges
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if changes.Name != nil {
+		if strings.TrimSpace(*changes.Name) == "" {
+			writeError(w, http.StatusBadRequest, errors.New("name can't be empty"))
+			return
+		}
+
+		project.name = *changes.Name
+	}
+
+	if changes.Description != nil {
+		project.description = *changes.Description
+	}
+
+	if changes.Color != nil {
+		if *changes.Color != "" && !ValidColor(*changes.Color) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid color %s", *changes.Color))
+			return
+		}
+
+		project.color = *changes.Color
+	}
+
+	if changes.Owner != nil {
+		project.owner = *changes.Owner
+	}
+
+	if changes.TargetDate != nil {
+		if project.targetDate, err = parseDate(*changes.TargetDate); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("target_date must be YYYY-MM-DD"))
+			return
+		}
+	}
+
+	if changes.Lanes != nil {
+		if project.lanes, err = parseLanes(formatLanes(*changes.Lanes)); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := s.projects.Update(project); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	s.getProject(w, r)
+}
+
+// Move a project up (negative delta) or down (positive delta) the list
+func (s *Server) moveProject(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Delta int `json:"delta"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.changeProject(w, r, func(id int) error {
+		return s.projects.Move(id, body.Delta)
+	})
+}
+
+// Permanently delete a project along with all of its tasks
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
+	project, err := s.projects.Resolve(r.PathValue("ref"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if err := s.projects.Delete(project.id); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get the user with the name, adding them if they're new, see UserDB.Ensure
+func (s *Server) ensureUser(w http.ResponseWriter, r *http.Request) {
+	var body UserJSON
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if strings.TrimSpace(body.Name) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+
+	user, err := s.users.Ensure(body.Name, body.DisplayName)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userToJSON(user))
+}
+
+// Find a user by name or display name, see UserDB.Find
+func (s *Server) findUser(w http.ResponseWriter, r *http.Request) {
+	var body UserJSON
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if strings.TrimSpace(body.Name) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+
+	user, err := s.users.Find(body.Name)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userToJSON(user))
+}
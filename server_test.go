@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testToken = "secret"
+
+// Send a request to the server, with the token unless it's empty, decoding
+// the response into out if it's given
+func request(t *testing.T, s *Server, method, path, token string, body any, out any) int {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	if out != nil && w.Code < 300 {
+		if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+
+	return w.Code
+}
+
+func TestServerAuth(t *testing.T) {
+	s := NewServer(newTestStore(t), testToken, User{})
+
+	tests := []struct {
+		path, token string
+		want        int
+	}{
+		{"/api/projects", "", http.StatusUnauthorized},
+		{"/api/projects", "wrong", http.StatusUnauthorized},
+		{"/api/projects", testToken, http.StatusOK},
+		// The API description is open to everyone
+		{"/api/openapi.yaml", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if got := request(t, s, http.MethodGet, tt.path, tt.token, nil, nil); got != tt.want {
+			t.Errorf("GET %s with token %q = %d, want %d", tt.path, tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestServerTasks(t *testing.T) {
+	s := NewServer(newTestStore(t), testToken, User{})
+
+	var project ProjectJSON
+	code := request(t, s, http.MethodPost, "/api/projects", testToken, NewProjectJSON{
+		ProjectJSON: ProjectJSON{Name: "Api", Prefix: "API"},
+		Tasks:       []TaskJSON{{Name: "Set up CI"}},
+	}, &project)
+	if code != http.StatusCreated {
+		t.Fatalf("creating a project = %d, want %d", code, http.StatusCreated)
+	}
+
+	code = request(t, s, http.MethodPost, "/api/projects", testToken, ProjectJSON{Name: "Other", Prefix: "API"}, nil)
+	if code != http.StatusConflict {
+		t.Errorf("creating a project with a taken prefix = %d, want %d", code, http.StatusConflict)
+	}
+
+	var task TaskJSON
+	code = request(t, s, http.MethodPost, "/api/tasks", testToken, TaskJSON{Name: "Write docs", Project: "API"}, &task)
+	if code != http.StatusCreated {
+		t.Fatalf("creating a task = %d, want %d", code, http.StatusCreated)
+	}
+
+	if task.Key != "API-2" {
+		t.Errorf("new task's key = %s, want API-2, after the starter task", task.Key)
+	}
+
+	code = request(t, s, http.MethodPost, "/api/tasks", testToken, TaskJSON{Project: "API"}, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("creating a task without a name = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	bad := map[string]TaskJSON{
+		"without a project":       {Name: "Lost"},
+		"for an unknown assignee": {Name: "Lost", Project: "API", AssigneeId: 99},
+		"by an unknown creator":   {Name: "Lost", Project: "API", CreatedById: 99},
+	}
+
+	for name, body := range bad {
+		if code := request(t, s, http.MethodPost, "/api/tasks", testToken, body, nil); code != http.StatusBadRequest {
+			t.Errorf("creating a task %s = %d, want %d", name, code, http.StatusBadRequest)
+		}
+	}
+
+	var list []TaskJSON
+	request(t, s, http.MethodGet, "/api/tasks?project=API&status=todo", testToken, nil, &list)
+	if len(list) != 2 {
+		t.Errorf("listed %d tasks, want 2", len(list))
+	}
+
+	var updated TaskJSON
+	name := "Write the docs"
+	code = request(t, s, http.MethodPatch, "/api/tasks/API-2", testToken, map[string]any{
+		"name":    name,
+		"version": task.Version,
+	}, &updated)
+	if code != http.StatusOK || updated.Name != name {
+		t.Errorf("updating a task = %d %q, want %d %q", code, updated.Name, http.StatusOK, name)
+	}
+
+	// Its version is out of date now
+	code = request(t, s, http.MethodPatch, "/api/tasks/API-2", testToken, map[string]any{
+		"name":    "Something else",
+		"version": task.Version,
+	}, nil)
+	if code != http.StatusConflict {
+		t.Errorf("updating at an old version = %d, want %d", code, http.StatusConflict)
+	}
+
+	// An epic can't go in anything
+	code = request(t, s, http.MethodPatch, "/api/tasks/API-1", testToken, map[string]any{"epic": true}, nil)
+	if code != http.StatusOK {
+		t.Fatalf("making an epic = %d, want %d", code, http.StatusOK)
+	}
+
+	code = request(t, s, http.MethodPatch, "/api/tasks/API-1", testToken, map[string]any{"parent": "API-1"}, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("putting an epic in an epic = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	code = request(t, s, http.MethodDelete, "/api/tasks/API-2", testToken, nil, nil)
+	if code >= 300 {
+		t.Errorf("deleting a task = %d", code)
+	}
+
+	code = request(t, s, http.MethodGet, "/api/tasks/API-2", testToken, nil, nil)
+	if code != http.StatusNotFound {
+		t.Errorf("getting a deleted task = %d, want %d", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Who is at the board. Run locally there's just the one session, but over
+// SSH every connection gets its own, with screens of its own.
+type Session struct {
+	user User
+	ssh  bool // Over SSH, where nothing may be run on the server, like an editor
+}
+
+func NewSession(user User) *Session {
+	return &Session{user: user}
+}
+
+// The model to run for the session, starting on the projects table
+func (s *Session) Start() tea.Model {
+	return NewRouter(NewProjectsTable(s))
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A time-boxed iteration of a project. Tasks are planned into a sprint, and
+// the ones that aren't done when it closes carry on into the next.
+type Sprint struct {
+	Id        int
+	ProjectId int
+	Name      string
+	Start     time.Time
+	End       time.Time // The last day of the sprint
+	Closed    time.Time // Zero while the sprint is open
+
+	// Every task planned into the sprint, and the ones that are done.
+	// Once the sprint is closed, these are as they were when it closed.
+	Planned         int
+	Completed       int
+	PlannedPoints   float64
+	CompletedPoints float64
+}
+
+func (s Sprint) Open() bool {
+	return s.Closed.IsZero()
+}
+
+// e.g. "6 Oct – 19 Oct"
+func (s Sprint) Dates() string {
+	return s.Start.Format("2 Jan") + " – " + s.End.Format("2 Jan")
+}
+
+func (s Sprint) validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("A sprint needs a name")
+	}
+
+	if s.Start.IsZero() || s.End.IsZero() {
+		return fmt.Errorf("A sprint needs start and end dates, like %s", dateFormat)
+	}
+
+	if s.End.Before(s.Start) {
+		return errors.New("A sprint can't end before it starts")
+	}
+
+	return nil
+}
+
+// Sprints belong to a project, and go when it's deleted
+func (p *ProjectDB) CreateSprintTable() error {
+	_, err := p.db.Exec(`
+    CREATE TABLE IF NOT EXISTS sprints (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        project_id INTEGER NOT NULL,
+        name TEXT NOT NULL,
+        start_date TEXT NOT NULL,
+        end_date TEXT NOT NULL,
+        closed_at TEXT,
+        planned INTEGER NOT NULL DEFAULT 0,
+        completed INTEGER NOT NULL DEFAULT 0,
+        planned_points REAL NOT NULL DEFAULT 0,
+        completed_points REAL NOT NULL DEFAULT 0,
+        FOREIGN KEY (project_id) REFERENCES projects (id)
+    )
+    `)
+
+	return err
+}
+
+// Open sprints count their tasks as they are now, and closed ones keep the
+// counts from when they closed
+const sprintSelect = `SELECT sprints.id, sprints.project_id, sprints.name,
+    sprints.start_date, sprints.end_date, COALESCE(sprints.closed_at, ''),
+    CASE WHEN sprints.closed_at IS NULL THEN COALESCE(live.planned, 0) ELSE sprints.planned END,
+    CASE WHEN sprints.closed_at IS NULL THEN COALESCE(live.completed, 0) ELSE sprints.completed END,
+    CASE WHEN sprints.closed_at IS NULL THEN COALESCE(live.planned_points, 0) ELSE sprints.planned_points END,
+    CASE WHEN sprints.closed_at IS NULL THEN COALESCE(live.completed_points, 0) ELSE sprints.completed_points END
+    FROM sprints
+    LEFT JOIN (` + sprintCounts + ` GROUP BY sprint_id) AS live ON live.sprint_id = sprints.id`
+
+const sprintCounts = `SELECT sprint_id, COUNT(*) AS planned,
+    SUM(status = 2) AS completed,
+    SUM(COALESCE(estimate, 0)) AS planned_points,
+    SUM(CASE WHEN status = 2 THEN COALESCE(estimate, 0) ELSE 0 END) AS completed_points
+    FROM tasks WHERE NOT archived`
+
+func scanSprint(row scanner) (Sprint, error) {
+	var sprint Sprint
+	var start, end, closed string
+	err := row.Scan(
+		&sprint.Id,
+		&sprint.ProjectId,
+		&sprint.Name,
+		&start,
+		&end,
+		&closed,
+		&sprint.Planned,
+		&sprint.Completed,
+		&sprint.PlannedPoints,
+		&sprint.CompletedPoints,
+	)
+	if err != nil {
+		return sprint, err
+	}
+
+	if sprint.Start, err = parseDate(start); err != nil {
+		return sprint, err
+	}
+
+	if sprint.End, err = parseDate(end); err != nil {
+		return sprint, err
+	}
+
+	sprint.Closed, err = parseTimestamp(closed)
+
+	return sprint, err
+}
+
+// The project's sprints, in the order they start
+func (p *ProjectDB) GetSprints(project int) ([]Sprint, error) {
+	rows, err := p.db.Query(
+		sprintSelect+" WHERE sprints.project_id = ? ORDER BY sprints.start_date, sprints.id",
+		project,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sprints []Sprint
+	for rows.Next() {
+		sprint, err := scanSprint(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sprints = append(sprints, sprint)
+	}
+
+	return sprints, rows.Err()
+}
+
+func (p *ProjectDB) GetSprint(id int) (Sprint, error) {
+	return scanSprint(p.db.QueryRow(sprintSelect+" WHERE sprints.id = ?", id))
+}
+
+// Returns the sprint as it was saved. sql.ErrNoRows if the project doesn't
+// exist.
+func (p *ProjectDB) InsertSprint(sprint Sprint) (Sprint, error) {
+	var exists bool
+	err := p.db.QueryRow("SELECT EXISTS (SELECT 1 FROM projects WHERE id = ?)", sprint.ProjectId).Scan(&exists)
+	if err != nil {
+		return Sprint{}, err
+	}
+
+	if !exists {
+		return Sprint{}, sql.ErrNoRows
+	}
+
+	result, err := p.db.Exec(
+		"INSERT INTO sprints (project_id, name, start_date, end_date) VALUES (?, ?, ?, ?)",
+		sprint.ProjectId,
+		sprint.Name,
+		formatDate(sprint.Start),
+		formatDate(sprint.End),
+	)
+	if err != nil {
+		return Sprint{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Sprint{}, err
+	}
+
+	return p.GetSprint(int(id))
+}
+
+// Close the sprint, keeping its counts as they are now, and carry the tasks
+// that aren't done on into the project's next open sprint, or back to the
+// backlog if there isn't one. Closing a closed sprint changes nothing.
+func (p *ProjectDB) CloseSprint(id int) (Sprint, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return Sprint{}, err
+	}
+	defer tx.Rollback()
+
+	sprint, err := scanSprint(tx.QueryRow(sprintSelect+" WHERE sprints.id = ?", id))
+	if err != nil || !sprint.Open() {
+		return sprint, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE sprints SET closed_at = datetime('now'),
+            planned = ?, completed = ?, planned_points = ?, completed_points = ?
+        WHERE id = ?`,
+		sprint.Planned,
+		sprint.Completed,
+		sprint.PlannedPoints,
+		sprint.CompletedPoints,
+		id,
+	)
+	if err != nil {
+		return Sprint{}, err
+	}
+
+	var next sql.NullInt64
+	err = tx.QueryRow(
+		`SELECT id FROM sprints
+        WHERE project_id = ? AND closed_at IS NULL AND id != ? AND start_date >= ?
+        ORDER BY start_date, id LIMIT 1`,
+		sprint.ProjectId,
+		id,
+		formatDate(sprint.Start),
+	).Scan(&next)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Sprint{}, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE tasks SET sprint_id = ?, version = version + 1, updated_at = datetime('now')
+        WHERE sprint_id = ? AND status != ? AND NOT archived`,
+		next,
+		id,
+		done,
+	)
+	if err != nil {
+		return Sprint{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Sprint{}, err
+	}
+
+	return p.GetSprint(id)
+}
+
+// The open sprint that started most recently, which stays current until
+// it's closed. False if no open sprint has started yet.
+func currentSprint(sprints []Sprint) (Sprint, bool) {
+	now := time.Now()
+
+	var current Sprint
+	for _, s := range sprints {
+		if s.Open() && !s.Start.After(now) && (current.Id == 0 || !s.Start.Before(current.Start)) {
+			current = s
+		}
+	}
+
+	return current, current.Id != 0
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fields of the sprint form, in tab order
+const (
+	sprintNameField = iota
+	sprintStartField
+	sprintEndField
+	numSprintFields
+)
+
+// Sprints are two weeks unless the dates are changed
+const defaultSprintDays = 14
+
+// Form for planning a new sprint in a project
+type SprintForm struct {
+	project Project
+	fields  []textinput.Model
+	focused int
+	err     string
+	width   int
+	height  int
+}
+
+// The name and dates are filled in to follow on from the project's last
+// sprint, or to start today if it's already over
+func NewSprintForm(project Project, sprints []Sprint, width, height int) *SprintForm {
+	f := &SprintForm{project: project, fields: make([]textinput.Model, numSprintFields), width: width, height: height}
+	for i := range f.fields {
+		f.fields[i] = textinput.New()
+	}
+
+	f.fields[sprintNameField].Prompt = "Name: "
+	f.fields[sprintStartField].Prompt = "Starts: "
+	f.fields[sprintStartField].Placeholder = dateFormat
+	f.fields[sprintEndField].Prompt = "Ends: "
+	f.fields[sprintEndField].Placeholder = dateFormat
+
+	start, _ := parseDate(time.Now().Format(dateFormat))
+	if len(sprints) > 0 {
+		if next := sprints[len(sprints)-1].End.AddDate(0, 0, 1); next.After(start) {
+			start = next
+		}
+	}
+
+	f.fields[sprintNameField].SetValue(fmt.Sprintf("Sprint %d", len(sprints)+1))
+	f.fields[sprintStartField].SetValue(start.Format(dateFormat))
+	f.fields[sprintEndField].SetValue(start.AddDate(0, 0, defaultSprintDays-1).Format(dateFormat))
+
+	f.fields[sprintNameField].Focus()
+
+	return f
+}
+
+func (f *SprintForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *SprintForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.width = msg.Width
+		f.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return f, tea.Quit
+		case "ctrl+b", "esc":
+			return f, Pop()
+		case "tab", "down":
+			f.cycleFocus(1)
+			return f, textinput.Blink
+		case "shift+tab", "up":
+			f.cycleFocus(-1)
+			return f, textinput.Blink
+		case "enter":
+			sprint, err := f.sprint()
+			if err != nil {
+				f.err = err.Error()
+				return f, nil
+			}
+
+			// Save once the sprints are back, so they get the result
+			return f, tea.Sequence(Pop(), insertSprint(sprint))
+		default:
+			var cmd tea.Cmd
+			f.fields[f.focused], cmd = f.fields[f.focused].Update(msg)
+			return f, cmd
+		}
+	}
+
+	return f, nil
+}
+
+func (f *SprintForm) cycleFocus(delta int) {
+	f.fields[f.focused].Blur()
+	f.focused = (f.focused + delta + numSprintFields) % numSprintFields
+	f.fields[f.focused].Focus()
+}
+
+// The sprint as it's filled in, if it's valid
+func (f *SprintForm) sprint() (Sprint, error) {
+	sprint := Sprint{ProjectId: f.project.id, Name: strings.TrimSpace(f.fields[sprintNameField].Value())}
+
+	var err error
+	sprint.Start, err = parseDate(strings.TrimSpace(f.fields[sprintStartField].Value()))
+	if err == nil {
+		sprint.End, err = parseDate(strings.TrimSpace(f.fields[sprintEndField].Value()))
+	}
+
+	if err != nil {
+		return sprint, fmt.Errorf("Dates must look like %s", dateFormat)
+	}
+
+	return sprint, sprint.validate()
+}
+
+func insertSprint(sprint Sprint) tea.Cmd {
+	return func() tea.Msg {
+		sprint, err := store.InsertSprint(sprint)
+		if err != nil {
+			return ErrorMsg{err: err}
+		}
+
+		return SprintCreatedMsg{sprint: sprint}
+	}
+}
+
+func (f *SprintForm) View() string {
+	views := []string{"New sprint in " + f.project.name, ""}
+	for _, field := range f.fields {
+		views = append(views, field.View())
+	}
+
+	if f.err != "" {
+		views = append(views, errorStyle.Render(f.err))
+	}
+
+	views = append(views, "", helpStyle.Render("tab: next field • enter: save • esc: back"))
+
+	render := newProjectStyle.Render(lipgloss.JoinVertical(lipgloss.Left, views...))
+	return lipgloss.Place(f.width, f.height, lipgloss.Center, lipgloss.Center, render)
+}
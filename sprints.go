@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A project's sprints, oldest first, with how each of them went
+type Sprints struct {
+	session  *Session
+	project  Project
+	sprints  []Sprint
+	cursor   int
+	revision int   // Revision of the data the sprints were loaded at
+	err      error // Why the sprints couldn't be loaded, reported when they open
+	confirm  Confirm
+	notice   string
+	help     help.Model
+	keys     sprintsKeyMap
+	width    int
+	height   int
+}
+
+func NewSprints(session *Session, project Project, width, height int) *Sprints {
+	s := &Sprints{
+		session: session,
+		project: project,
+		help:    help.New(),
+		keys:    sprintsKeys,
+		width:   width,
+		height:  height,
+	}
+
+	s.err = s.load()
+
+	// Start on the current sprint, or the latest one
+	s.cursor = max(len(s.sprints)-1, 0)
+	if current, ok := currentSprint(s.sprints); ok {
+		s.selectSprint(current.Id)
+	}
+
+	return s
+}
+
+func (s *Sprints) load() error {
+	s.revision = currentRevision()
+
+	sprints, err := store.GetSprints(s.project.id)
+	if err != nil {
+		return err
+	}
+
+	s.sprints = sprints
+	if s.cursor >= len(s.sprints) {
+		s.cursor = max(len(s.sprints)-1, 0)
+	}
+
+	return nil
+}
+
+func (s *Sprints) selectSprint(id int) {
+	for i, sprint := range s.sprints {
+		if sprint.Id == id {
+			s.cursor = i
+		}
+	}
+}
+
+func (s *Sprints) selected() (Sprint, bool) {
+	if len(s.sprints) == 0 {
+		return Sprint{}, false
+	}
+
+	return s.sprints[s.cursor], true
+}
+
+// The open sprint a closing sprint's unfinished tasks go on to, the same
+// one ProjectDB.CloseSprint picks. False if they go back to the backlog.
+func (s *Sprints) nextSprint(sprint Sprint) (Sprint, bool) {
+	for _, next := range s.sprints {
+		if next.Id != sprint.Id && next.Open() && !next.Start.Before(sprint.Start) {
+			return next, true
+		}
+	}
+
+	return Sprint{}, false
+}
+
+func (s *Sprints) Init() tea.Cmd {
+	if s.err != nil {
+		return reportError(s.err)
+	}
+
+	return nil
+}
+
+func (s *Sprints) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+	case RevisionMsg:
+		if msg.revision != s.revision {
+			if err := s.load(); err != nil {
+				return s, reportError(err)
+			}
+		}
+	case SprintCreatedMsg:
+		if err := s.load(); err != nil {
+			return s, reportError(err)
+		}
+
+		s.selectSprint(msg.sprint.Id)
+	case SprintClosedMsg:
+		if err := s.load(); err != nil {
+			return s, reportError(err)
+		}
+
+		s.notice = fmt.Sprintf("%s closed: %s", msg.sprint.Name, sprintSummary(msg.sprint))
+	case tea.KeyMsg:
+		if s.confirm.Open() {
+			var cmd tea.Cmd
+			s.confirm, cmd = s.confirm.Update(msg)
+			return s, cmd
+		}
+
+		s.notice = ""
+
+		switch {
+		case key.Matches(msg, s.keys.Quit):
+			return s, tea.Quit
+		case key.Matches(msg, s.keys.Back):
+			return s, Pop()
+		case key.Matches(msg, s.keys.Up):
+			if s.cursor > 0 {
+				s.cursor--
+			}
+		case key.Matches(msg, s.keys.Down):
+			if s.cursor < len(s.sprints)-1 {
+				s.cursor++
+			}
+		case key.Matches(msg, s.keys.Help):
+			s.help.ShowAll = !s.help.ShowAll
+		case key.Matches(msg, s.keys.New):
+			return s, Push(NewSprintForm(s.project, s.sprints, s.width, s.height))
+		case key.Matches(msg, s.keys.Close):
+			sprint, ok := s.selected()
+			if !ok || !sprint.Open() {
+				return s, nil
+			}
+
+			left := sprint.Planned - sprint.Completed
+			prompt := fmt.Sprintf("Close %s?", sprint.Name)
+			if left > 0 {
+				where := "back to the backlog"
+				if next, ok := s.nextSprint(sprint); ok {
+					where = "on to " + next.Name
+				}
+
+				prompt = fmt.Sprintf("Close %s? %s that aren't done will go %s.", sprint.Name, taskCount(left), where)
+			}
+
+			var cmd tea.Cmd
+			s.confirm, cmd = Ask(prompt, closeSprint(sprint.Id))
+			return s, cmd
+		}
+	}
+
+	return s, nil
+}
+
+// Sent once a sprint is saved
+type SprintCreatedMsg struct {
+	sprint Sprint
+}
+
+// Sent once a sprint is closed, with its summary
+type SprintClosedMsg struct {
+	sprint Sprint
+}
+
+func closeSprint(id int) tea.Cmd {
+	return func() tea.Msg {
+		sprint, err := store.CloseSprint(id)
+		if err != nil {
+			return ErrorMsg{err: err}
+		}
+
+		return SprintClosedMsg{sprint: sprint}
+	}
+}
+
+// Planned against completed, e.g. "5 of 8 tasks done · 13 of 21 pts"
+func sprintSummary(s Sprint) string {
+	if s.Planned == 0 {
+		return "no tasks planned"
+	}
+
+	summary := fmt.Sprintf("%d of %s done", s.Completed, taskCount(s.Planned))
+	if s.PlannedPoints > 0 {
+		summary += " · " + strconv.FormatFloat(s.CompletedPoints, 'f', -1, 64) + " of " + formatEstimate(s.PlannedPoints)
+	}
+
+	return summary
+}
+
+// Where the sprint is at, e.g. "current" or "closed 19 Oct"
+func sprintState(s Sprint, current bool) string {
+	today, _ := parseDate(time.Now().Format(dateFormat))
+
+	switch {
+	case !s.Open():
+		return "closed " + s.Closed.Format("2 Jan")
+	case current && s.End.Before(today):
+		return "current, past its end"
+	case current:
+		return "current"
+	case s.Start.After(today):
+		return "planned"
+	default:
+		return "open"
+	}
+}
+
+func (s *Sprints) View() string {
+	view := s.sprintsView()
+	if s.confirm.Open() {
+		return s.confirm.View(view, s.width, s.height)
+	}
+
+	return view
+}
+
+func (s *Sprints) sprintsView() string {
+	header := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		boardHeaderStyle.Background(projectColor(s.project.color)).Render(s.project.name),
+		helpStyle.Render(" · Sprints"),
+	)
+
+	lines := []string{header, ""}
+
+	if len(s.sprints) == 0 {
+		lines = append(lines, helpStyle.Render("No sprints yet, press n to plan one"))
+	}
+
+	current, _ := currentSprint(s.sprints)
+	for i, sprint := range s.sprints {
+		line := fmt.Sprintf(
+			"%-16s %-16s %-22s %s",
+			sprint.Name,
+			sprint.Dates(),
+			sprintState(sprint, sprint.Id == current.Id),
+			sprintSummary(sprint),
+		)
+
+		if i == s.cursor {
+			lines = append(lines, agendaSelectedStyle.Render(line))
+		} else {
+			lines = append(lines, agendaItemStyle.Render(line))
+		}
+	}
+
+	lines = append(lines, "", helpStyle.Render(s.notice), s.help.View(s.keys))
+
+	render := lipgloss.JoinVertical(lipgloss.Left, lines...)
+
+	return lipgloss.Place(s.width, s.height, lipgloss.Center, lipgloss.Center, render)
+}
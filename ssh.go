@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/activeterm"
+	bm "github.com/charmbracelet/wish/bubbletea"
+	"github.com/charmbracelet/wish/logging"
+	"github.com/muesli/termenv"
+)
+
+const (
+	defaultSSHAddr = "127.0.0.1:23234"
+
+	// Made in the data dir the first time the board is served over SSH
+	hostKeyName = "ssh_host_ed25519"
+)
+
+// A public key someone can log in with, and who they are on the board
+type sshKey struct {
+	key  ssh.PublicKey
+	user string
+}
+
+// Parse the keys from the ssh_keys section of the config file, which lists
+// the public keys of each user name in authorized_keys format.
+func loadSSHKeys(users map[string][]string) ([]sshKey, error) {
+	var keys []sshKey
+	for user, lines := range users {
+		for _, line := range lines {
+			key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+			if err != nil {
+				return nil, fmt.Errorf("ssh key of %s: %w", user, err)
+			}
+
+			keys = append(keys, sshKey{key: key, user: user})
+		}
+	}
+
+	return keys, nil
+}
+
+// The user name the key belongs to, if it's allowed in at all
+func sshUser(keys []sshKey, key ssh.PublicKey) (string, bool) {
+	for _, k := range keys {
+		if ssh.KeysEqual(k.key, key) {
+			return k.user, true
+		}
+	}
+
+	return "", false
+}
+
+// Serve the board over SSH until it fails, for `kanban-cli ssh-serve`. Every
+// connection gets a board of its own, as the user their key belongs to.
+func sshServe(args []string) error {
+	flags := flag.NewFlagSet("ssh-serve", flag.ExitOnError)
+	addr := flags.String("addr", defaultSSHAddr, "address to listen on")
+	flags.Parse(args)
+
+	keys, err := loadSSHKeys(config.SSHKeys)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("add people's public keys under ssh_keys in %s to serve over SSH", getConfigPath())
+	}
+
+	// Log connections to the terminal rather than the debug log
+	log.SetOutput(os.Stderr)
+
+	// The styles are shared by every session, so they can't follow each
+	// client's terminal. 256 colors works in just about any of them.
+	lipgloss.SetColorProfile(termenv.ANSI256)
+
+	server, err := wish.NewServer(
+		wish.WithAddress(*addr),
+		wish.WithHostKeyPath(filepath.Join(getDbPath(), hostKeyName)),
+		wish.WithPublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
+			_, ok := sshUser(keys, key)
+			return ok
+		}),
+		wish.WithMiddleware(
+			bm.MiddlewareWithProgramHandler(sshProgram(keys), termenv.ANSI256),
+			activeterm.Middleware(),
+			logging.Middleware(),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Serving the board over SSH on %s", *addr)
+
+	err = server.ListenAndServe()
+	if errors.Is(err, ssh.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Make the program for an SSH connection, with a session of its own
+func sshProgram(keys []sshKey) bm.ProgramHandler {
+	return func(s ssh.Session) *tea.Program {
+		name, ok := sshUser(keys, s.PublicKey())
+		if !ok {
+			wish.Fatalln(s, "unknown key")
+			return nil
+		}
+
+		user, err := store.EnsureUser(name, "")
+		if err != nil {
+			log.Println(err)
+			wish.Fatalln(s, "couldn't look up your user")
+			return nil
+		}
+
+		session := NewSession(user)
+		session.ssh = true
+
+		p := tea.NewProgram(session.Start(), bm.MakeOptions(s)...)
+
+		// Stops with the connection
+		go watchRevision(s.Context(), p)
+
+		return p
+	}
+}
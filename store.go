@@ -0,0 +1,316 @@
+package main
+
+import (
+	"time"
+)
+
+// Everything the TUI reads and writes goes through the store, so the board
+// can live in the local database or on a kanban server (see --remote).
+// Errors follow the db: sql.ErrNoRows when something doesn't exist, and
+// ErrConflict when a task was changed by someone else first.
+type Store interface {
+	GetTask(id int) (Task, error)
+	// By key, e.g. "API-17"
+	GetTaskByKey(key string) (Task, error)
+	GetTasksByStatus(status status, project int, filter TaskFilter) ([]Task, error)
+	GetAgenda() ([]Task, error)
+	// Returns the task as it was saved, with its key
+	InsertTask(task Task) (Task, error)
+	// See TaskDB.Update
+	UpdateTask(task Task) error
+	NextStatus(task Task) (Task, error)
+	AssignTask(id int, user int) error
+	DeleteTask(id int) error
+	// All or nothing, see TaskDB.UpdateMany
+	UpdateTasks(ids []int, change BulkChange) error
+
+	GetBlockers(id int) ([]Task, error)
+	GetBlocking(id int) ([]Task, error)
+	// ErrCycle if the blocker is already waiting on the task
+	AddBlocker(id int, blocker int) error
+	RemoveBlocker(id int, blocker int) error
+
+	// Across every open project
+	GetEpics() ([]Task, error)
+	GetChildren(id int) ([]Task, error)
+
+	// Make any instances of recurring tasks that are due by now, see
+	// TaskDB.CatchUp. Returns the new tasks.
+	RunRecurrences() ([]Task, error)
+
+	// Stops any other timer the user has running, only one runs at a time
+	StartTimer(task int, user int) (TimeEntry, error)
+	// sql.ErrNoRows if the user has no timer running
+	StopTimer(user int) (TimeEntry, error)
+	GetRunningTimer(user int) (TimeEntry, error)
+	GetTimeEntries(task int) ([]TimeEntry, error)
+	// See TaskDB.TimeReport
+	GetTimeReport(since time.Time, user int) ([]TimeTotal, error)
+
+	GetProject(id int) (Project, error)
+	GetProjectsByStatus(s projectStatus) ([]Project, error)
+	GetProjectStats(id int) (ProjectStats, error)
+	PrefixExists(prefix string) (bool, error)
+	// The tasks go in the new project, in the same transaction
+	InsertProject(project Project, tasks []Task) (Project, error)
+	UpdateProject(project Project) error
+	RenameProject(id int, name string) error
+	MoveProject(id int, delta int) error
+	ArchiveProject(id int) error
+	UnarchiveProject(id int) error
+	DeleteProject(id int) error
+
+	// In the order they start
+	GetSprints(project int) ([]Sprint, error)
+	// Returns the sprint as it was saved
+	InsertSprint(sprint Sprint) (Sprint, error)
+	// Carries unfinished tasks on, see ProjectDB.CloseSprint
+	CloseSprint(id int) (Sprint, error)
+
+	// Only the saved ones, see allTemplates for the ones in files too
+	GetTemplates() (Templates, error)
+	SaveTaskTemplate(template TaskTemplate) error
+	SaveProjectTemplate(template ProjectTemplate) error
+	DeleteTemplate(kind, name string) error
+
+	FindUser(name string) (User, error)
+	EnsureUser(name, displayName string) (User, error)
+
+	// The revision of the data, which goes up with every change
+	Revision() (int, error)
+}
+
+// Set up in main, depending on --remote
+var store Store
+
+// Task stats of a project
+type ProjectStats struct {
+	counts       [numStatus]int // Number of tasks by status
+	overdue      int
+	lastActivity time.Time // Zero if the project has no tasks
+}
+
+// The local database
+type SQLiteStore struct {
+	tasks     *TaskDB
+	projects  *ProjectDB
+	users     *UserDB
+	templates *TemplateDB
+}
+
+func NewSQLiteStore() *SQLiteStore {
+	db := openDB()
+
+	return &SQLiteStore{
+		tasks:     &TaskDB{db},
+		projects:  &ProjectDB{db},
+		users:     &UserDB{db},
+		templates: &TemplateDB{db},
+	}
+}
+
+func (s *SQLiteStore) GetTask(id int) (Task, error) {
+	return s.tasks.Get(id)
+}
+
+func (s *SQLiteStore) GetTaskByKey(key string) (Task, error) {
+	return s.tasks.GetByKey(key)
+}
+
+func (s *SQLiteStore) GetTasksByStatus(status status, project int, filter TaskFilter) ([]Task, error) {
+	return s.tasks.GetByStatus(status, project, filter)
+}
+
+func (s *SQLiteStore) GetAgenda() ([]Task, error) {
+	return s.tasks.GetAgenda()
+}
+
+func (s *SQLiteStore) InsertTask(task Task) (Task, error) {
+	result, err := s.tasks.Insert(task)
+	if err != nil {
+		return task, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return task, err
+	}
+
+	return s.tasks.Get(int(id))
+}
+
+func (s *SQLiteStore) UpdateTask(task Task) error {
+	return s.tasks.Update(task)
+}
+
+func (s *SQLiteStore) NextStatus(task Task) (Task, error) {
+	return s.tasks.NextStatus(task)
+}
+
+func (s *SQLiteStore) AssignTask(id int, user int) error {
+	return s.tasks.Assign(id, user)
+}
+
+func (s *SQLiteStore) UpdateTasks(ids []int, change BulkChange) error {
+	return s.tasks.UpdateMany(ids, change)
+}
+
+func (s *SQLiteStore) DeleteTask(id int) error {
+	return s.tasks.Delete(id)
+}
+
+func (s *SQLiteStore) GetBlockers(id int) ([]Task, error) {
+	return s.tasks.GetBlockers(id)
+}
+
+func (s *SQLiteStore) GetBlocking(id int) ([]Task, error) {
+	return s.tasks.GetBlocking(id)
+}
+
+func (s *SQLiteStore) AddBlocker(id int, blocker int) error {
+	return s.tasks.AddBlocker(id, blocker)
+}
+
+func (s *SQLiteStore) RemoveBlocker(id int, blocker int) error {
+	return s.tasks.RemoveBlocker(id, blocker)
+}
+
+func (s *SQLiteStore) GetEpics() ([]Task, error) {
+	return s.tasks.GetEpics()
+}
+
+func (s *SQLiteStore) GetChildren(id int) ([]Task, error) {
+	return s.tasks.GetChildren(id)
+}
+
+func (s *SQLiteStore) RunRecurrences() ([]Task, error) {
+	return s.tasks.CatchUp()
+}
+
+func (s *SQLiteStore) StartTimer(task int, user int) (TimeEntry, error) {
+	return s.tasks.StartTimer(task, user)
+}
+
+func (s *SQLiteStore) StopTimer(user int) (TimeEntry, error) {
+	return s.tasks.StopTimer(user)
+}
+
+func (s *SQLiteStore) GetRunningTimer(user int) (TimeEntry, error) {
+	return s.tasks.RunningTimer(user)
+}
+
+func (s *SQLiteStore) GetTimeEntries(task int) ([]TimeEntry, error) {
+	return s.tasks.GetTimeEntries(task)
+}
+
+func (s *SQLiteStore) GetTimeReport(since time.Time, user int) ([]TimeTotal, error) {
+	return s.tasks.TimeReport(since, user)
+}
+
+func (s *SQLiteStore) GetProject(id int) (Project, error) {
+	return s.projects.Get(id)
+}
+
+func (s *SQLiteStore) GetProjectsByStatus(status projectStatus) ([]Project, error) {
+	return s.projects.GetByStatus(status)
+}
+
+func (s *SQLiteStore) GetProjectStats(id int) (ProjectStats, error) {
+	var stats ProjectStats
+
+	rows, err := s.tasks.GetProjectTasksByStatus(id)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, row := range rows {
+		stats.counts[row.status] = row.count
+	}
+
+	activity, err := s.tasks.GetProjectActivity(id)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.overdue = activity.overdue
+	stats.lastActivity = activity.lastActivity
+
+	return stats, nil
+}
+
+func (s *SQLiteStore) PrefixExists(prefix string) (bool, error) {
+	return s.projects.PrefixExists(prefix)
+}
+
+func (s *SQLiteStore) InsertProject(project Project, tasks []Task) (Project, error) {
+	id, err := s.projects.Insert(project, tasks)
+	if err != nil {
+		return project, err
+	}
+
+	return s.projects.Get(id)
+}
+
+func (s *SQLiteStore) UpdateProject(project Project) error {
+	return s.projects.Update(project)
+}
+
+func (s *SQLiteStore) RenameProject(id int, name string) error {
+	return s.projects.Rename(id, name)
+}
+
+func (s *SQLiteStore) MoveProject(id int, delta int) error {
+	return s.projects.Move(id, delta)
+}
+
+func (s *SQLiteStore) ArchiveProject(id int) error {
+	return s.projects.ArchiveProject(id)
+}
+
+func (s *SQLiteStore) UnarchiveProject(id int) error {
+	return s.projects.UnarchiveProject(id)
+}
+
+func (s *SQLiteStore) DeleteProject(id int) error {
+	return s.projects.Delete(id)
+}
+
+func (s *SQLiteStore) GetSprints(project int) ([]Sprint, error) {
+	return s.projects.GetSprints(project)
+}
+
+func (s *SQLiteStore) InsertSprint(sprint Sprint) (Sprint, error) {
+	return s.projects.InsertSprint(sprint)
+}
+
+func (s *SQLiteStore) CloseSprint(id int) (Sprint, error) {
+	return s.projects.CloseSprint(id)
+}
+
+func (s *SQLiteStore) GetTemplates() (Templates, error) {
+	return s.templates.GetAll()
+}
+
+func (s *SQLiteStore) SaveTaskTemplate(template TaskTemplate) error {
+	return s.templates.Save(taskTemplateKind, template.Name, template)
+}
+
+func (s *SQLiteStore) SaveProjectTemplate(template ProjectTemplate) error {
+	return s.templates.Save(projectTemplateKind, template.Name, template)
+}
+
+func (s *SQLiteStore) DeleteTemplate(kind, name string) error {
+	return s.templates.Delete(kind, name)
+}
+
+func (s *SQLiteStore) FindUser(name string) (User, error) {
+	return s.users.Find(name)
+}
+
+func (s *SQLiteStore) EnsureUser(name, displayName string) (User, error) {
+	return s.users.Ensure(name, displayName)
+}
+
+func (s *SQLiteStore) Revision() (int, error) {
+	return getRevision(s.tasks.db)
+}
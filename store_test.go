@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjectStatsLeaveOutArchived(t *testing.T) {
+	s := newTestStore(t)
+	project := addTestProject(t, s, "API")
+
+	late := NewTask(todo, "Write docs", "", 0, project.id)
+	late.Due = time.Now().AddDate(0, 0, -3)
+	addTestTask(t, s, late)
+
+	archived := addTestTask(t, s, late)
+	if err := s.UpdateTasks([]int{archived.Id}, BulkChange{Archive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := s.GetProjectStats(project.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.counts[todo] != 1 {
+		t.Errorf("tasks in todo = %d, want 1", stats.counts[todo])
+	}
+
+	if stats.overdue != 1 {
+		t.Errorf("overdue tasks = %d, want 1", stats.overdue)
+	}
+}
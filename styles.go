@@ -1,6 +1,11 @@
 package main
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 const (
 	grey           = lipgloss.Color("241")
@@ -9,3 +14,24 @@ const (
 	secondary      = "#663399"
 	secondaryColor = lipgloss.Color(secondary)
 )
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Colors can be given as hex ("#FF8800") or as an ANSI color number ("212")
+func ValidColor(c string) bool {
+	if hexColor.MatchString(c) {
+		return true
+	}
+
+	n, err := strconv.Atoi(c)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// The color of a project, falling back to the highlight color
+func projectColor(c string) lipgloss.Color {
+	if c == "" || !ValidColor(c) {
+		return highlightColor
+	}
+
+	return lipgloss.Color(c)
+}
@@ -1,11 +1,10 @@
 package main
 
 import (
-	"log"
+	"io"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/gobeam/stringy"
 )
 
 const (
@@ -66,19 +65,16 @@ func (s *SwimLane) SetHeight(h int) {
 
 // This will create a new list, meant to be rendered next to N number of other lists,
 // where N is equal to the number total lists. This number is passed in as a divisor.
-func (s *SwimLane) Init(width int, height int, project int, status status) SwimLane {
+// Tasks with an id in marked are drawn marked. If the tasks can't be read,
+// the lane is still made, empty, along with the error.
+func (s *SwimLane) Init(width int, height int, project Project, status status, filter TaskFilter, marked map[int]bool) (SwimLane, error) {
 	s.laneStatus = status
 
-	title := stringy.New(status.String()).Title()
+	title := project.LaneTitle(status)
 	s.title = title
 
 	// Fetch items from the DB
-	taskDB := GetDB()
-	defer taskDB.db.Close()
-	tasks, err := taskDB.GetByStatus(status, project)
-	if err != nil {
-		log.Fatal(err)
-	}
+	tasks, err := store.GetTasksByStatus(status, project.id, filter)
 
 	var items []list.Item
 	for _, task := range tasks {
@@ -88,7 +84,7 @@ func (s *SwimLane) Init(width int, height int, project int, status status) SwimL
 	vOffset := (verticalPad * 2) + (bordersize * 2)
 	hOffset := (horizontalPad * 2) + (bordersize * 2)
 
-	d := list.NewDefaultDelegate()
+	d := taskDelegate{DefaultDelegate: list.NewDefaultDelegate(), marked: marked}
 	d.Styles.SelectedTitle = listFocusItemStyle
 	d.Styles.SelectedDesc = listFocusItemDescStyle
 
@@ -100,10 +96,65 @@ func (s *SwimLane) Init(width int, height int, project int, status status) SwimL
 	// Set styles
 	s.list.Styles.Title = listTitleStyle
 
-	return *s
+	return *s, err
+}
+
+// Draws tasks the same as the default delegate, with a mark on the ones
+// picked out for a bulk change and on the ones still blocked
+type taskDelegate struct {
+	list.DefaultDelegate
+	marked map[int]bool
+}
+
+type laneTask struct {
+	Task
+	marked bool
+}
+
+func (t laneTask) Title() string {
+	title := t.Task.Title()
+
+	if t.Epic {
+		title = "◆ " + title
+	}
+
+	if t.Blocked() {
+		title = "⊘ " + title
+	}
+
+	if t.marked {
+		title = "● " + title
+	}
+
+	return title
+}
+
+func (d taskDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if t, ok := item.(Task); ok {
+		item = laneTask{Task: t, marked: d.marked[t.Id]}
+	}
+
+	d.DefaultDelegate.Render(w, m, index, item)
+}
+
+// The tasks in the lane, in order
+func (s *SwimLane) Tasks() []Task {
+	var tasks []Task
+	for _, item := range s.list.Items() {
+		tasks = append(tasks, item.(Task))
+	}
+
+	return tasks
 }
 
 func (s *SwimLane) View() string {
+	// Lanes with estimated tasks show how much work is in them, kept up
+	// as tasks move in and out
+	s.list.Title = s.title
+	if total, estimated := totalEstimate(s.Tasks()); estimated {
+		s.list.Title += " · " + formatEstimate(total)
+	}
+
 	if s.focused {
 		return focusedStyle.Render(s.list.View())
 	}
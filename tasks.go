@@ -4,8 +4,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
-	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // The number of swim lanes should be dynamic, as well as find their
@@ -36,12 +38,141 @@ func GetStatusFromString(s string) (int, error) {
 	return -1, errors.New(fmt.Sprintf("Invalid status %s", s))
 }
 
+type priority int
+
+const (
+	noPriority priority = iota
+	low
+	medium
+	high
+)
+
+var priority_strings = [...]string{"none", "low", "medium", "high"}
+
+func (p priority) String() string {
+	return priority_strings[p]
+}
+
+func GetPriorityFromString(s string) (priority, error) {
+	for i, v := range priority_strings {
+		if v == s {
+			return priority(i), nil
+		}
+	}
+
+	return noPriority, fmt.Errorf("Invalid priority %s", s)
+}
+
+// Tags are stored comma separated, and typed in the same way
+func parseTags(s string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(s, ",") {
+		tags = addTag(tags, tag)
+	}
+
+	return tags
+}
+
+func formatTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+// Add the tag unless the task has it already
+func addTag(tags []string, tag string) []string {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || slices.Contains(tags, tag) {
+		return tags
+	}
+
+	return append(tags, tag)
+}
+
 type Task struct {
 	Id        int
 	Name      string
 	Info      string
 	Status    status
 	ProjectId int
+	Seq       int       // Number of the task within its project
+	Prefix    string    // Task key prefix of the project, read-only
+	Due       time.Time // Zero if the task has no due date
+	CreatedAt time.Time // Zero for tasks from before it was kept, read-only
+	UpdatedAt time.Time // Last time the task was changed, read-only
+	Assignee  int       // User id, zero if unassigned
+	CreatedBy int       // User id, zero if unknown
+	Version   int       // Bumped on every change, see TaskDB.Update
+	Priority  priority
+	Tags      []string
+	Archived  bool // Off the board, but kept
+	// Rule for when the task comes round again, see Recurrence. Empty if
+	// it doesn't.
+	Recurrence string
+
+	// Points or hours, see Config.EstimateUnit. Zero if it isn't estimated.
+	Estimate float64
+
+	// The sprint the task is planned into, zero for the backlog. Not
+	// changed by TaskDB.Update, see BulkChange.
+	SprintId int
+
+	// An epic has other tasks, from any project, as its children. Epics
+	// aren't in epics themselves. Neither of these is changed by
+	// TaskDB.Update, see BulkChange.
+	Epic     bool
+	ParentId int // The epic the task is in, zero if none
+
+	// How many of an epic's children are in each lane, archived ones
+	// aside, read-only
+	Children [numStatus]int
+
+	// When the task last moved to done, zero if it isn't done. Kept up to
+	// date by the database, read-only.
+	CompletedAt time.Time
+
+	// Number of tasks blocking this one that aren't done, read-only
+	OpenBlockers int
+
+	// Names of the assignee and creator, read-only
+	AssigneeName  string
+	CreatedByName string
+
+	// Key and name of the epic the task is in, read-only
+	ParentKey  string
+	ParentName string
+}
+
+// Narrows down the tasks shown on a board. The zero value shows everything.
+type TaskFilter struct {
+	Assignee int // Only tasks assigned to this user
+	Sprint   int // Only tasks planned into this sprint
+	Epic     int // Only this epic and the tasks in it
+}
+
+// The SQL condition for the filter, to be ANDed onto a query of tasks
+func (f TaskFilter) where() (string, []any) {
+	var conds []string
+	var args []any
+
+	if f.Assignee != 0 {
+		conds = append(conds, "tasks.assignee_id = ?")
+		args = append(args, f.Assignee)
+	}
+
+	if f.Sprint != 0 {
+		conds = append(conds, "tasks.sprint_id = ?")
+		args = append(args, f.Sprint)
+	}
+
+	if f.Epic != 0 {
+		conds = append(conds, "(tasks.id = ? OR tasks.parent_id = ?)")
+		args = append(args, f.Epic, f.Epic)
+	}
+
+	if len(conds) == 0 {
+		return "1", nil
+	}
+
+	return strings.Join(conds, " AND "), args
 }
 
 type CreateTaskMsg struct {
@@ -51,12 +182,16 @@ type CreateTaskMsg struct {
 type EditTaskMsg struct {
 	task  Task
 	index int
+	from  status // Lane the task was in before it was edited
 }
 
 type DeleteTaskMsg struct {
-	index int
+	task Task
 }
 
+// Sent when a recurring task is done and its next instance has been made
+type RecurMsg struct{}
+
 func (t *Task) Next() {
 	if t.Status == done {
 		t.Status = todo
@@ -65,52 +200,101 @@ func (t *Task) Next() {
 	}
 }
 
+// The human-friendly key of the task, e.g. "API-17". Tasks that have not
+// been saved yet don't have one.
+func (t Task) Key() string {
+	if t.Prefix == "" || t.Seq == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s-%d", t.Prefix, t.Seq)
+}
+
+// Split a task key like "API-17" into its project prefix and sequence number.
+// Keys are matched case-insensitively, so "api-17" works too.
+func ParseTaskKey(key string) (string, int, error) {
+	i := strings.LastIndex(key, "-")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("Invalid task key %s", key)
+	}
+
+	prefix := strings.ToUpper(key[:i])
+	seq, err := strconv.Atoi(key[i+1:])
+	if err != nil || seq <= 0 || !ValidPrefix(prefix) {
+		return "", 0, fmt.Errorf("Invalid task key %s", key)
+	}
+
+	return prefix, seq, nil
+}
+
 // Implement the bubbles/list.Item interface
 func (t Task) FilterValue() string {
+	if k := t.Key(); k != "" {
+		return k + " " + t.Name
+	}
+
 	return t.Name
 }
 
 func (t Task) Title() string {
-	return t.Name
-}
+	title := t.Name
+	if k := t.Key(); k != "" {
+		title = k + " " + title
+	}
 
-func (t Task) Description() string {
-	return t.Info
+	// The assignee's initials act as their avatar
+	if t.AssigneeName != "" {
+		title = fmt.Sprintf("[%s] %s", Initials(t.AssigneeName), title)
+	}
+
+	return title
 }
 
-func (t *Task) Merge(newT Task) {
-	newValues := reflect.ValueOf(&newT).Elem()
-	oldValues := reflect.ValueOf(t).Elem()
+func (t Task) Description() string {
+	var details []string
+	switch {
+	case t.Epic:
+		details = append(details, epicSummary(t))
+	case t.ParentKey != "":
+		details = append(details, "in "+t.ParentKey)
+	}
 
-	// Loop through new values and assign them to a new task
-	for i := 0; i < newValues.NumField(); i++ {
-		fieldName := newValues.Type().Field(i).Name
-		newField := newValues.Field(i).Interface()
+	if t.Priority != noPriority {
+		details = append(details, t.Priority.String())
+	}
 
-		// Ignore ID fields
-		if fieldName == "Id" {
-			continue
-		}
+	for _, tag := range t.Tags {
+		details = append(details, "#"+tag)
+	}
 
-		if oldValues.CanSet() {
-			if v, ok := newField.(int64); ok && newField != 0 {
-				oldValues.Field(i).SetInt(v)
-				continue
-			}
+	if !t.Due.IsZero() {
+		details = append(details, "due "+t.Due.Format(dateFormat))
+	}
 
-			if v, ok := newField.(string); ok && newField != "" {
-				oldValues.Field(i).SetString(v)
-				continue
-			}
+	if t.Recurrence != "" {
+		details = append(details, "↻ "+t.Recurrence)
+	}
 
-			if v, ok := newField.(status); ok {
-				oldValues.Field(i).SetInt(int64(v))
-				continue
-			}
+	if t.Estimate != 0 {
+		details = append(details, formatEstimate(t.Estimate))
+	}
 
-			log.Printf("Unsupported value for %s : %T", newField, newField)
-		}
+	if t.Info != "" {
+		details = append(details, t.Info)
 	}
+
+	return strings.Join(details, " · ")
+}
+
+// Waiting on tasks that aren't done yet. Done tasks don't count as blocked,
+// whatever their blockers are up to.
+func (t Task) Blocked() bool {
+	return t.Status != done && t.OpenBlockers > 0
+}
+
+// Not done, and due before today
+func (t Task) Overdue() bool {
+	return t.Status != done && !t.Due.IsZero() && t.Due.Format(dateFormat) < today()
 }
 
 func NewTask(status status, name string, info string, id int, project int) Task {
@@ -121,6 +305,9 @@ type TaskDB struct {
 	db *sql.DB
 }
 
+// Returned by Update when someone else changed the task since it was read
+var ErrConflict = errors.New("task was changed by someone else")
+
 func (t *TaskDB) CreateTable() error {
 	// Create our table if it doesn't exist.
 	// A task should have the following data:
@@ -129,6 +316,18 @@ func (t *TaskDB) CreateTable() error {
 	// info
 	// status
 	// project_id --> references the id of a projects row
+	// seq --> number of the task within its project, used for the task key
+	// due_date --> optional, formatted as dateFormat
+	// created_at
+	// updated_at
+	// assignee_id --> references the id of a users row
+	// created_by --> references the id of a users row
+	// version --> bumped on every change, to catch conflicting edits
+	// priority --> see priority_strings
+	// tags --> comma separated
+	// archived --> archived tasks are left off the board
+	// recurrence --> see Recurrence, only on the latest task of a series
+	// epic, parent_id --> see Task.Epic
 	createStatement := `
     CREATE TABLE IF NOT EXISTS tasks (
         id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -136,139 +335,720 @@ func (t *TaskDB) CreateTable() error {
         info TEXT,
         status INTEGER,
         project_id INTEGER NOT NULL,
-        FOREIGN KEY (project_id) REFERENCES projects (id)
+        seq INTEGER,
+        due_date TEXT,
+        created_at TEXT,
+        updated_at TEXT,
+        assignee_id INTEGER,
+        created_by INTEGER,
+        version INTEGER NOT NULL DEFAULT 1,
+        priority INTEGER NOT NULL DEFAULT 0,
+        tags TEXT,
+        archived INTEGER NOT NULL DEFAULT 0,
+        recurrence TEXT,
+        estimate REAL,
+        completed_at TEXT,
+        sprint_id INTEGER,
+        epic INTEGER NOT NULL DEFAULT 0,
+        parent_id INTEGER,
+        FOREIGN KEY (project_id) REFERENCES projects (id),
+        FOREIGN KEY (assignee_id) REFERENCES users (id),
+        FOREIGN KEY (created_by) REFERENCES users (id),
+        FOREIGN KEY (sprint_id) REFERENCES sprints (id),
+        FOREIGN KEY (parent_id) REFERENCES tasks (id)
     )
     `
 
 	_, err := t.db.Exec(createStatement)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// Tasks from before these columns existed have no known times
+	for _, column := range []string{"due_date", "created_at", "updated_at"} {
+		if _, err := addColumn(t.db, "tasks", column, "TEXT"); err != nil {
+			return err
+		}
+	}
+
+	for _, column := range []string{"assignee_id", "created_by"} {
+		if _, err := addColumn(t.db, "tasks", column, "INTEGER REFERENCES users (id)"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := addColumn(t.db, "tasks", "version", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+
+	for _, column := range []string{"priority", "archived", "epic"} {
+		if _, err := addColumn(t.db, "tasks", column, "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+
+	for _, column := range []string{"tags", "recurrence"} {
+		if _, err := addColumn(t.db, "tasks", column, "TEXT"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := addColumn(t.db, "tasks", "estimate", "REAL"); err != nil {
+		return err
+	}
+
+	for _, column := range []string{"sprint_id", "parent_id"} {
+		if _, err := addColumn(t.db, "tasks", column, "INTEGER"); err != nil {
+			return err
+		}
+	}
+
+	// Tasks that were done before this was kept were done by the time
+	// they last changed, as far as anyone can tell
+	added, err := addColumn(t.db, "tasks", "completed_at", "TEXT")
+	if err != nil {
+		return err
+	}
+
+	if added {
+		_, err := t.db.Exec("UPDATE tasks SET completed_at = COALESCE(updated_at, created_at) WHERE status = ?", done)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := createCompletedTriggers(t.db); err != nil {
+		return err
+	}
+
+	added, err = addColumn(t.db, "tasks", "seq", "INTEGER")
+	if err != nil {
+		return err
+	}
+
+	if added {
+		return t.backfillSeq()
+	}
+
+	return nil
+}
+
+// However a task gets to done, whether from the board, in bulk or through the
+// API, the time it did is kept for burndown charts. Moving it out of done
+// clears it.
+func createCompletedTriggers(db *sql.DB) error {
+	statements := []string{
+		`CREATE TRIGGER IF NOT EXISTS tasks_insert_completed AFTER INSERT ON tasks
+        WHEN NEW.status = 2
+        BEGIN UPDATE tasks SET completed_at = datetime('now') WHERE id = NEW.id; END`,
+		`CREATE TRIGGER IF NOT EXISTS tasks_update_completed AFTER UPDATE OF status ON tasks
+        WHEN NEW.status IS NOT OLD.status
+        BEGIN UPDATE tasks SET completed_at = CASE WHEN NEW.status = 2 THEN datetime('now') END WHERE id = NEW.id; END`,
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Number existing tasks within their project in the order they were
+// created, and bring each project's counter up to date.
+func (t *TaskDB) backfillSeq() error {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, project_id FROM tasks ORDER BY id")
+	if err != nil {
+		return err
+	}
+
+	seqs := make(map[int]int)
+	ids := make(map[int]int)
+	var order []int
+	for rows.Next() {
+		var id, project int
+		if err := rows.Scan(&id, &project); err != nil {
+			rows.Close()
+			return err
+		}
+
+		seqs[project]++
+		ids[id] = seqs[project]
+		order = append(order, id)
+	}
+	rows.Close()
+
+	for _, id := range order {
+		if _, err := tx.Exec("UPDATE tasks SET seq = ? WHERE id = ?", ids[id], id); err != nil {
+			return err
+		}
+	}
+
+	for project, seq := range seqs {
+		if _, err := tx.Exec("UPDATE projects SET task_seq = ? WHERE id = ?", seq, project); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (t *TaskDB) Insert(name, info string, project int, status status) (sql.Result, error) {
-	result, err := t.db.Exec(
-		"INSERT INTO tasks (name, info, status, project_id) VALUES(?, ?, ?, ?)",
-		name,
-		info,
-		status,
-		project,
+// Every task query selects the same columns, joined with its project for the
+// key prefix and with its epic, so they can all share scanTask. Blockers count
+// as open while their status isn't 2, which is done.
+const taskSelect = `SELECT tasks.id, tasks.name, tasks.info, tasks.status, tasks.project_id,
+    COALESCE(tasks.seq, 0), COALESCE(projects.prefix, ''),
+    COALESCE(tasks.due_date, ''), COALESCE(tasks.created_at, ''), COALESCE(tasks.updated_at, ''),
+    COALESCE(tasks.assignee_id, 0), COALESCE(tasks.created_by, 0), tasks.version,
+    tasks.priority, COALESCE(tasks.tags, ''), tasks.archived, COALESCE(tasks.recurrence, ''),
+    COALESCE(tasks.estimate, 0), COALESCE(tasks.completed_at, ''), COALESCE(tasks.sprint_id, 0),
+    tasks.epic, COALESCE(tasks.parent_id, 0),
+    COALESCE(children.todo, 0), COALESCE(children.in_progress, 0), COALESCE(children.done, 0),
+    (SELECT COUNT(*) FROM task_dependencies AS dep
+        JOIN tasks AS blocker ON blocker.id = dep.blocker_id
+        WHERE dep.task_id = tasks.id AND blocker.status != 2),
+    COALESCE(NULLIF(assignee.display_name, ''), assignee.name, ''),
+    COALESCE(NULLIF(creator.display_name, ''), creator.name, ''),
+    COALESCE(parent_project.prefix || '-' || parent.seq, ''), COALESCE(parent.name, '')
+    FROM tasks
+    LEFT JOIN projects ON projects.id = tasks.project_id
+    LEFT JOIN users AS assignee ON assignee.id = tasks.assignee_id
+    LEFT JOIN users AS creator ON creator.id = tasks.created_by
+    LEFT JOIN tasks AS parent ON parent.id = tasks.parent_id
+    LEFT JOIN projects AS parent_project ON parent_project.id = parent.project_id
+    LEFT JOIN (` + childCounts + `) AS children ON children.parent_id = tasks.id`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row scanner) (Task, error) {
+	var task Task
+	var due, created, updated, tags, completed string
+	err := row.Scan(
+		&task.Id,
+		&task.Name,
+		&task.Info,
+		&task.Status,
+		&task.ProjectId,
+		&task.Seq,
+		&task.Prefix,
+		&due,
+		&created,
+		&updated,
+		&task.Assignee,
+		&task.CreatedBy,
+		&task.Version,
+		&task.Priority,
+		&tags,
+		&task.Archived,
+		&task.Recurrence,
+		&task.Estimate,
+		&completed,
+		&task.SprintId,
+		&task.Epic,
+		&task.ParentId,
+		&task.Children[todo],
+		&task.Children[inProgress],
+		&task.Children[done],
+		&task.OpenBlockers,
+		&task.AssigneeName,
+		&task.CreatedByName,
+		&task.ParentKey,
+		&task.ParentName,
 	)
+	if err != nil {
+		return task, err
+	}
+
+	if task.Due, err = parseDate(due); err != nil {
+		return task, err
+	}
+
+	task.Tags = parseTags(tags)
+
+	if task.CreatedAt, err = parseTimestamp(created); err != nil {
+		return task, err
+	}
 
-	return result, err
+	if task.CompletedAt, err = parseTimestamp(completed); err != nil {
+		return task, err
+	}
+
+	task.UpdatedAt, err = parseTimestamp(updated)
+
+	return task, err
+}
+
+func scanTasks(rows *sql.Rows) ([]Task, error) {
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// Inserts the task and hands it the next number in its project
+func (t *TaskDB) Insert(task Task) (sql.Result, error) {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := insertInTx(tx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, tx.Commit()
+}
+
+func insertInTx(tx *sql.Tx, task Task) (sql.Result, error) {
+	if task.ParentId != 0 {
+		if err := checkParent(tx, task.Id, task.ParentId, task.Epic); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := tx.Exec("UPDATE projects SET task_seq = task_seq + 1 WHERE id = ?", task.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.Exec(
+		`INSERT INTO tasks (name, info, status, project_id, seq, due_date, assignee_id, created_by,
+            priority, tags, recurrence, estimate, sprint_id, epic, parent_id, created_at, updated_at)
+        VALUES(?, ?, ?, ?, (SELECT task_seq FROM projects WHERE id = ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+            datetime('now'), datetime('now'))`,
+		task.Name,
+		task.Info,
+		task.Status,
+		task.ProjectId,
+		task.ProjectId,
+		formatDate(task.Due),
+		nullableId(task.Assignee),
+		nullableId(task.CreatedBy),
+		task.Priority,
+		formatTags(task.Tags),
+		nullableString(task.Recurrence),
+		nullableEstimate(task.Estimate),
+		nullableId(task.SprintId),
+		task.Epic,
+		nullableId(task.ParentId),
+	)
 }
 
 func (t *TaskDB) Delete(id int) error {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(deleteDependencies, id, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(deleteTimeEntries, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(releaseChildren, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tasks WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (t *TaskDB) Get(id int) (Task, error) {
+	return scanTask(t.db.QueryRow(taskSelect+" WHERE tasks.id = ?", id))
+}
+
+func (t *TaskDB) GetByKey(key string) (Task, error) {
+	prefix, seq, err := ParseTaskKey(key)
+	if err != nil {
+		return Task{}, err
+	}
+
+	return scanTask(t.db.QueryRow(taskSelect+" WHERE projects.prefix = ? AND tasks.seq = ?", prefix, seq))
+}
+
+// Look up a task by its key ("API-17") or, failing that, its database id.
+// This is what anything taking a task reference from the user should use.
+func (t *TaskDB) Resolve(ref string) (Task, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return t.Get(id)
+	}
+
+	return t.GetByKey(ref)
+}
+
+// Update the task's own fields: the name, info, status, due date, assignee,
+// priority, tags, recurrence and estimate. They're all written, so an empty
+// one clears the field. The version of the given task is the one it was
+// read at; if that's no longer the current version, ErrConflict is returned
+// and nothing changes. A zero version skips the check, overwriting whatever
+// is there. The project, sprint and epic are left alone, see UpdateWith.
+func (t *TaskDB) Update(task Task) error {
+	return t.UpdateWith(task, BulkChange{})
+}
+
+// Update the task, then make the change to it as well, all in one
+// transaction so that either both happen or neither does
+func (t *TaskDB) UpdateWith(task Task, change BulkChange) error {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateFieldsInTx(tx, task); err != nil {
+		return err
+	}
+
+	if change != (BulkChange{}) {
+		if err := updateInTx(tx, task.Id, change); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func updateFieldsInTx(tx *sql.Tx, task Task) error {
+	// Checking the version in the same statement means a change made
+	// since the task was read is caught, however recent
+	result, err := tx.Exec(
+		`UPDATE tasks SET name = ?, info = ?, status = ?, due_date = ?, assignee_id = ?, priority = ?,
+            tags = ?, recurrence = ?, estimate = ?, version = version + 1, updated_at = datetime('now')
+        WHERE id = ? AND (? = 0 OR version = ?)`,
+		task.Name,
+		task.Info,
+		task.Status,
+		formatDate(task.Due),
+		nullableId(task.Assignee),
+		task.Priority,
+		formatTags(task.Tags),
+		nullableString(task.Recurrence),
+		nullableEstimate(task.Estimate),
+		task.Id,
+		task.Version,
+		task.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	changed, err := result.RowsAffected()
+	if err != nil || changed > 0 {
+		return err
+	}
+
+	// Either there's no such task, or it has moved on
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ?)", task.Id).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	return ErrConflict
+}
+
+// Assign the task to a user, or unassign it with a zero user id, whatever
+// else has changed about it
+func (t *TaskDB) Assign(id int, user int) error {
 	_, err := t.db.Exec(
-		"DELETE FROM tasks WHERE id = ?",
+		"UPDATE tasks SET assignee_id = ?, version = version + 1, updated_at = datetime('now') WHERE id = ?",
+		nullableId(user),
 		id,
 	)
 
 	return err
 }
 
-func (t *TaskDB) Get(id int) (Task, error) {
-	var task Task
-	err := t.db.QueryRow("SELECT * FROM tasks WHERE id = ?", id).
-		Scan(
-			&task.Id,
-			&task.Name,
-			&task.Info,
-			&task.Status,
-			&task.ProjectId,
-		)
+// A change made to many tasks at once, see TaskDB.UpdateMany. Nil and zero
+// fields are left alone.
+type BulkChange struct {
+	Status    *status
+	Priority  *priority
+	Assignee  *int   // Zero unassigns
+	Sprint    *int   // An open sprint of the tasks' project, zero for the backlog
+	Epic      *bool  // Epics leave their own epic, and others let go of their children
+	Parent    *int   // An epic to put the tasks in, zero takes them out of theirs
+	Tag       string // Added to the tags of each task
+	ProjectId int    // Moves the tasks here, which gives them new keys
+	Archive   bool
+	Delete    bool
+}
 
-	return task, err
+// Make the change to every task, all in one transaction, so either all of
+// them change or none do. Versions aren't checked: the change overwrites
+// whatever is there.
+func (t *TaskDB) UpdateMany(ids []int, change BulkChange) error {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if err := updateInTx(tx, id, change); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (t *TaskDB) Update(task Task) error {
-	// Get current task
-	curr, err := t.Get(task.Id)
+func updateInTx(tx *sql.Tx, id int, change BulkChange) error {
+	if change.Delete {
+		if _, err := tx.Exec(deleteDependencies, id, id); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(deleteTimeEntries, id); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(releaseChildren, id); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec("DELETE FROM tasks WHERE id = ?", id)
+		return err
+	}
+
+	var project int
+	var tags string
+	var epic bool
+	err := tx.QueryRow("SELECT project_id, COALESCE(tags, ''), epic FROM tasks WHERE id = ?", id).Scan(&project, &tags, &epic)
 	if err != nil {
 		return err
 	}
 
-	// Mutate current task with updated task values
-	curr.Merge(task)
+	sets := []string{"version = version + 1", "updated_at = datetime('now')"}
+	var args []any
 
-	// Perform the update
-	_, mErr := t.db.Exec(
-		"UPDATE tasks SET name = ?, info = ?, status = ?, project_id = ? WHERE id = ?",
-		curr.Name,
-		curr.Info,
-		curr.Status,
-		curr.ProjectId,
-		curr.Id,
-	)
+	if change.Status != nil {
+		sets = append(sets, "status = ?")
+		args = append(args, *change.Status)
+	}
+
+	if change.Priority != nil {
+		sets = append(sets, "priority = ?")
+		args = append(args, *change.Priority)
+	}
+
+	if change.Assignee != nil {
+		sets = append(sets, "assignee_id = ?")
+		args = append(args, nullableId(*change.Assignee))
+	}
+
+	if change.Sprint != nil && *change.Sprint != 0 {
+		var ok bool
+		err := tx.QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM sprints WHERE id = ? AND project_id = ? AND closed_at IS NULL)",
+			*change.Sprint,
+			project,
+		).Scan(&ok)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return sql.ErrNoRows
+		}
+	}
+
+	if change.Sprint != nil {
+		sets = append(sets, "sprint_id = ?")
+		args = append(args, nullableId(*change.Sprint))
+	}
+
+	if change.Epic != nil {
+		epic = *change.Epic
+		sets = append(sets, "epic = ?")
+		args = append(args, epic)
+
+		if epic {
+			sets = append(sets, "parent_id = NULL")
+		} else if _, err := tx.Exec(releaseChildren, id); err != nil {
+			return err
+		}
+	}
 
-	return mErr
+	if change.Parent != nil && *change.Parent != 0 {
+		if err := checkParent(tx, id, *change.Parent, epic); err != nil {
+			return err
+		}
+	}
+
+	if change.Parent != nil {
+		sets = append(sets, "parent_id = ?")
+		args = append(args, nullableId(*change.Parent))
+	}
+
+	if change.Tag != "" {
+		sets = append(sets, "tags = ?")
+		args = append(args, formatTags(addTag(parseTags(tags), change.Tag)))
+	}
+
+	if change.Archive {
+		sets = append(sets, "archived = 1")
+	}
+
+	if change.ProjectId != 0 && change.ProjectId != project {
+		// The next number in the new project, the same as Insert
+		result, err := tx.Exec("UPDATE projects SET task_seq = task_seq + 1 WHERE id = ?", change.ProjectId)
+		if err != nil {
+			return err
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		// No such project
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+
+		// Sprints belong to the project, so the tasks leave theirs
+		sets = append(sets, "project_id = ?", "seq = (SELECT task_seq FROM projects WHERE id = ?)", "sprint_id = NULL")
+		args = append(args, change.ProjectId, change.ProjectId)
+	}
+
+	_, err = tx.Exec("UPDATE tasks SET "+strings.Join(sets, ", ")+" WHERE id = ?", append(args, id)...)
+
+	return err
 }
 
 func (t *TaskDB) NextStatus(task Task) (Task, error) {
 	// First, increment the task itself
 	task.Next()
 
-	// Then, update this task in the DB
-	err := t.Update(task)
+	// Moving it on and following it up go together, so a series can't be
+	// left done without a next instance
+	tx, err := t.db.Begin()
 	if err != nil {
 		return Task{}, err
 	}
+	defer tx.Rollback()
 
-	// Finally, return the task with the incremented status
-	return task, nil
+	if err := updateFieldsInTx(tx, task); err != nil {
+		return Task{}, err
+	}
+
+	// A recurring task that's done is followed by its next instance,
+	// which takes the recurrence over
+	if task.Status == done && task.Recurrence != "" {
+		if _, err := recurInTx(tx, task); err != nil {
+			return Task{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Task{}, err
+	}
+
+	// Finally, return the task as it is now, with the incremented status
+	// and version
+	return t.Get(task.Id)
 }
 
 func (t *TaskDB) GetAll() ([]Task, error) {
-	var tasks []Task
-
-	rows, err := t.db.Query("SELECT * FROM tasks")
+	rows, err := t.db.Query(taskSelect)
 	if err != nil {
 		return nil, err
 	}
 
-	for rows.Next() {
-		var task Task
-		err = rows.Scan(
-			&task.Id,
-			&task.Name,
-			&task.Info,
-			&task.Status,
-			&task.ProjectId,
-		)
-		if err != nil {
-			return nil, err
-		}
+	return scanTasks(rows)
+}
 
-		tasks = append(tasks, task)
+// Tasks in the project (zero for every project) and status (-1 for every
+// status) that match the filter. Archived tasks are either all that's
+// listed or left out.
+func (t *TaskDB) List(project int, status int, archived bool, filter TaskFilter) ([]Task, error) {
+	where, args := filter.where()
+	where += " AND tasks.archived = ?"
+	args = append(args, archived)
+
+	if project != 0 {
+		where += " AND tasks.project_id = ?"
+		args = append(args, project)
 	}
 
-	return tasks, err
+	if status != -1 {
+		where += " AND tasks.status = ?"
+		args = append(args, status)
+	}
+
+	rows, err := t.db.Query(taskSelect+" WHERE "+where+" ORDER BY tasks.id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTasks(rows)
 }
 
-func (t *TaskDB) GetByStatus(status status, project int) ([]Task, error) {
-	var tasks []Task
+func (t *TaskDB) GetByStatus(status status, project int, filter TaskFilter) ([]Task, error) {
+	where, args := filter.where()
+	args = append([]any{status, project}, args...)
 
-	rows, err := t.db.Query("SELECT * FROM tasks WHERE status = ? AND project_id = ?", status, project)
+	rows, err := t.db.Query(
+		taskSelect+" WHERE tasks.status = ? AND tasks.project_id = ? AND NOT tasks.archived AND "+where,
+		args...,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	for rows.Next() {
-		var task Task
-		err = rows.Scan(
-			&task.Id,
-			&task.Name,
-			&task.Info,
-			&task.Status,
-			&task.ProjectId,
-		)
-		if err != nil {
-			return nil, err
-		}
+	return scanTasks(rows)
+}
 
-		tasks = append(tasks, task)
+// Tasks from every open project that need attention today: anything in
+// progress, and anything not done that is due today or overdue. They're
+// ordered by project, then by due date.
+func (t *TaskDB) GetAgenda() ([]Task, error) {
+	rows, err := t.db.Query(
+		taskSelect+`
+        WHERE projects.status = ? AND tasks.status != ? AND NOT tasks.archived
+            AND (tasks.status = ? OR tasks.due_date <= ?)
+        ORDER BY projects.sort_order, projects.id, tasks.due_date IS NULL, tasks.due_date, tasks.id`,
+		open,
+		done,
+		inProgress,
+		today(),
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	return tasks, err
+	return scanTasks(rows)
 }
 
 // Deprecated: use the new projects table instead
@@ -300,7 +1080,7 @@ type ProjectTasksByStatusRow struct {
 }
 
 func (t *TaskDB) GetProjectTasksByStatus(projectId int) ([]ProjectTasksByStatusRow, error) {
-	rows, err := t.db.Query("SELECT status, id, COUNT(id) FROM tasks WHERE project_id = ? GROUP BY status", projectId)
+	rows, err := t.db.Query("SELECT status, id, COUNT(id) FROM tasks WHERE project_id = ? AND NOT archived GROUP BY status", projectId)
 	if err != nil {
 		return nil, err
 	}
@@ -320,6 +1100,31 @@ func (t *TaskDB) GetProjectTasksByStatus(projectId int) ([]ProjectTasksByStatusR
 	return tasks, nil
 }
 
+type ProjectActivityRow struct {
+	overdue      int
+	lastActivity time.Time
+}
+
+// Count the overdue tasks of a project, and find when any task was last changed
+func (t *TaskDB) GetProjectActivity(projectId int) (ProjectActivityRow, error) {
+	var row ProjectActivityRow
+	var last sql.NullString
+	err := t.db.QueryRow(
+		`SELECT COUNT(CASE WHEN status != ? AND due_date < ? THEN 1 END), MAX(updated_at)
+        FROM tasks WHERE project_id = ? AND NOT archived`,
+		done,
+		today(),
+		projectId,
+	).Scan(&row.overdue, &last)
+	if err != nil {
+		return row, err
+	}
+
+	row.lastActivity, err = parseTimestamp(last.String)
+
+	return row, err
+}
+
 // Deprecated: use the new projects table
 func (t *TaskDB) AddNewProject(projectName string) error {
 	_, err := t.db.Exec("INSERT INTO tasks (name, info, status, project) VALUES('A new beginning', '', 0, ?)", projectName)
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateConflict(t *testing.T) {
+	s := newTestStore(t)
+	project := addTestProject(t, s, "API")
+	task := addTestTask(t, s, NewTask(todo, "Write docs", "", 0, project.id))
+
+	// Someone else saves first
+	theirs := task
+	theirs.Name = "Write the docs"
+	if err := s.tasks.Update(theirs); err != nil {
+		t.Fatal(err)
+	}
+
+	ours := task
+	ours.Name = "Write more docs"
+	if err := s.tasks.Update(ours); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Update with a stale version = %v, want ErrConflict", err)
+	}
+
+	got, err := s.tasks.Get(task.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Name != theirs.Name {
+		t.Errorf("name after the conflict = %q, want %q", got.Name, theirs.Name)
+	}
+
+	if got.Version != task.Version+1 {
+		t.Errorf("version after the conflict = %d, want %d", got.Version, task.Version+1)
+	}
+
+	// At the current version, or with none, it goes through
+	ours.Version = got.Version
+	if err := s.tasks.Update(ours); err != nil {
+		t.Fatalf("Update at the current version: %v", err)
+	}
+
+	ours.Version = 0
+	ours.Name = "Overwritten"
+	if err := s.tasks.Update(ours); err != nil {
+		t.Fatalf("Update without a version: %v", err)
+	}
+
+	if got, _ := s.tasks.Get(task.Id); got.Name != "Overwritten" {
+		t.Errorf("name after overwriting = %q, want %q", got.Name, "Overwritten")
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of template, as stored
+const (
+	taskTemplateKind    = "task"
+	projectTemplateKind = "project"
+)
+
+// Template files live here in the config dir, so a team can keep them in a
+// repo and link or copy them in
+const templateDir = "templates"
+
+// Fills in the task form. Checklist items are added to the description as
+// markdown checkboxes.
+type TaskTemplate struct {
+	Name        string   `yaml:"name" json:"name"` // What it's listed as
+	Title       string   `yaml:"title,omitempty" json:"title"`
+	Description string   `yaml:"description,omitempty" json:"description"`
+	Checklist   []string `yaml:"checklist,omitempty" json:"checklist"`
+	Tags        []string `yaml:"tags,omitempty" json:"tags"`
+}
+
+// Starts a project off with its lanes and tasks
+type ProjectTemplate struct {
+	Name        string         `yaml:"name" json:"name"`
+	Description string         `yaml:"description,omitempty" json:"description"`
+	Lanes       []string       `yaml:"lanes,omitempty" json:"lanes"` // One title per lane, or none for the usual ones
+	Tasks       []TaskTemplate `yaml:"tasks,omitempty" json:"tasks"` // Added to the first lane
+}
+
+// A template file, or every template there is
+type Templates struct {
+	Tasks    []TaskTemplate    `yaml:"tasks,omitempty" json:"tasks"`
+	Projects []ProjectTemplate `yaml:"projects,omitempty" json:"projects"`
+}
+
+// The description of a task made from the template, checklist and all
+func (t TaskTemplate) Info() string {
+	lines := []string{}
+	if t.Description != "" {
+		lines = append(lines, t.Description)
+	}
+
+	if len(t.Checklist) > 0 && t.Description != "" {
+		lines = append(lines, "")
+	}
+
+	for _, item := range t.Checklist {
+		lines = append(lines, "- [ ] "+item)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Starter tasks in a project template can leave the title out, and go by
+// their name
+func (t TaskTemplate) TaskName() string {
+	if t.Title != "" {
+		return t.Title
+	}
+
+	return t.Name
+}
+
+func (t TaskTemplate) validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("Task templates need a name")
+	}
+
+	return nil
+}
+
+func (p ProjectTemplate) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("Project templates need a name")
+	}
+
+	if _, err := parseLanes(formatLanes(p.Lanes)); err != nil {
+		return fmt.Errorf("Project template %s: %w", p.Name, err)
+	}
+
+	for _, task := range p.Tasks {
+		if task.TaskName() == "" {
+			return fmt.Errorf("Project template %s: starter tasks need a title", p.Name)
+		}
+	}
+
+	return nil
+}
+
+// Templates saved in the database, kept as YAML so they look the same as the
+// ones in files
+type TemplateDB struct {
+	db *sql.DB
+}
+
+func (t *TemplateDB) CreateTable() error {
+	_, err := t.db.Exec(`
+    CREATE TABLE IF NOT EXISTS templates (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        kind TEXT NOT NULL,
+        name TEXT NOT NULL,
+        body TEXT NOT NULL,
+        UNIQUE (kind, name)
+    )
+    `)
+
+	return err
+}
+
+func (t *TemplateDB) GetAll() (Templates, error) {
+	var templates Templates
+
+	rows, err := t.db.Query("SELECT kind, body FROM templates ORDER BY name")
+	if err != nil {
+		return templates, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind, body string
+		if err := rows.Scan(&kind, &body); err != nil {
+			return templates, err
+		}
+
+		switch kind {
+		case taskTemplateKind:
+			var task TaskTemplate
+			if err := yaml.Unmarshal([]byte(body), &task); err != nil {
+				return templates, err
+			}
+
+			templates.Tasks = append(templates.Tasks, task)
+		case projectTemplateKind:
+			var project ProjectTemplate
+			if err := yaml.Unmarshal([]byte(body), &project); err != nil {
+				return templates, err
+			}
+
+			templates.Projects = append(templates.Projects, project)
+		}
+	}
+
+	return templates, rows.Err()
+}
+
+// Save the template, replacing any of the same kind and name
+func (t *TemplateDB) Save(kind, name string, template any) error {
+	body, err := yaml.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.db.Exec(
+		`INSERT INTO templates (kind, name, body) VALUES (?, ?, ?)
+        ON CONFLICT (kind, name) DO UPDATE SET body = excluded.body`,
+		kind,
+		name,
+		string(body),
+	)
+
+	return err
+}
+
+// Deleting one that isn't there is fine
+func (t *TemplateDB) Delete(kind, name string) error {
+	_, err := t.db.Exec("DELETE FROM templates WHERE kind = ? AND name = ?", kind, name)
+
+	return err
+}
+
+// Read every *.yaml file in the templates dir next to the config file. A
+// missing dir is the same as an empty one.
+func loadTemplateFiles() (Templates, error) {
+	var templates Templates
+
+	dir := filepath.Join(filepath.Dir(getConfigPath()), templateDir)
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return templates, err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return templates, err
+		}
+
+		var t Templates
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			return templates, fmt.Errorf("%s: %w", file, err)
+		}
+
+		for _, task := range t.Tasks {
+			if err := task.validate(); err != nil {
+				return templates, fmt.Errorf("%s: %w", file, err)
+			}
+		}
+
+		for _, project := range t.Projects {
+			if err := project.validate(); err != nil {
+				return templates, fmt.Errorf("%s: %w", file, err)
+			}
+		}
+
+		templates.Tasks = append(templates.Tasks, t.Tasks...)
+		templates.Projects = append(templates.Projects, t.Projects...)
+	}
+
+	return templates, nil
+}
+
+// The saved templates along with the ones from files, by name. A saved
+// template takes the place of a file one with the same name.
+func allTemplates() (Templates, error) {
+	templates, err := store.GetTemplates()
+	if err != nil {
+		return templates, err
+	}
+
+	files, err := loadTemplateFiles()
+	if err != nil {
+		return templates, err
+	}
+
+	for _, task := range files.Tasks {
+		if !slices.ContainsFunc(templates.Tasks, func(t TaskTemplate) bool { return t.Name == task.Name }) {
+			templates.Tasks = append(templates.Tasks, task)
+		}
+	}
+
+	for _, project := range files.Projects {
+		if !slices.ContainsFunc(templates.Projects, func(p ProjectTemplate) bool { return p.Name == project.Name }) {
+			templates.Projects = append(templates.Projects, project)
+		}
+	}
+
+	sort.SliceStable(templates.Tasks, func(i, j int) bool {
+		return strings.ToLower(templates.Tasks[i].Name) < strings.ToLower(templates.Tasks[j].Name)
+	})
+	sort.SliceStable(templates.Projects, func(i, j int) bool {
+		return strings.ToLower(templates.Projects[i].Name) < strings.ToLower(templates.Projects[j].Name)
+	})
+
+	return templates, nil
+}
+
+// A project template made from an existing project: its lanes, and the tasks
+// that aren't done as starter tasks
+func projectTemplateFrom(project Project) (ProjectTemplate, error) {
+	template := ProjectTemplate{
+		Name:        project.name,
+		Description: project.description,
+		Lanes:       project.lanes,
+	}
+
+	for _, st := range []status{todo, inProgress} {
+		tasks, err := store.GetTasksByStatus(st, project.id, TaskFilter{})
+		if err != nil {
+			return template, err
+		}
+
+		for _, task := range tasks {
+			template.Tasks = append(template.Tasks, TaskTemplate{
+				Name:        task.Name,
+				Description: task.Info,
+				Tags:        task.Tags,
+			})
+		}
+	}
+
+	return template, nil
+}
+
+// The starter tasks of the template, made by the user, for a new project
+func starterTasks(template ProjectTemplate, user int) []Task {
+	var tasks []Task
+	for _, t := range template.Tasks {
+		task := NewTask(todo, t.TaskName(), t.Info(), 0, 0)
+		task.Tags = t.Tags
+		task.CreatedBy = user
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks
+}
+
+// Templates in a picker, which filters on the name
+type taskTemplateItem struct {
+	template TaskTemplate
+}
+
+func (i taskTemplateItem) Title() string {
+	return i.template.Name
+}
+
+func (i taskTemplateItem) Description() string {
+	var details []string
+	if i.template.Title != "" {
+		details = append(details, i.template.Title)
+	}
+
+	if n := len(i.template.Checklist); n > 0 {
+		details = append(details, fmt.Sprintf("%d checklist items", n))
+	}
+
+	for _, tag := range i.template.Tags {
+		details = append(details, "#"+tag)
+	}
+
+	return strings.Join(details, " · ")
+}
+
+func (i taskTemplateItem) FilterValue() string {
+	return i.template.Name
+}
+
+type projectTemplateItem struct {
+	template ProjectTemplate
+}
+
+func (i projectTemplateItem) Title() string {
+	return i.template.Name
+}
+
+func (i projectTemplateItem) Description() string {
+	details := []string{taskCount(len(i.template.Tasks))}
+	if len(i.template.Lanes) > 0 {
+		details = append(details, formatLanes(i.template.Lanes))
+	}
+
+	return strings.Join(details, " · ")
+}
+
+func (i projectTemplateItem) FilterValue() string {
+	return i.template.Name
+}
+
+func taskTemplateItems(templates Templates) []list.Item {
+	var items []list.Item
+	for _, t := range templates.Tasks {
+		items = append(items, taskTemplateItem{template: t})
+	}
+
+	return items
+}
+
+func projectTemplateItems(templates Templates) []list.Item {
+	var items []list.Item
+	for _, t := range templates.Projects {
+		items = append(items, projectTemplateItem{template: t})
+	}
+
+	return items
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/muesli/reflow/truncate"
+)
+
+// A stretch of time someone spent on a task. Each person has at most one
+// timer running at a time.
+type TimeEntry struct {
+	Id       int
+	TaskId   int
+	TaskKey  string // e.g. "API-17"
+	TaskName string
+	UserId   int
+	UserName string
+	Start    time.Time
+	Stop     time.Time // Zero while the timer is running
+}
+
+func (e TimeEntry) Running() bool {
+	return e.Stop.IsZero()
+}
+
+// How long the entry lasted, or has lasted so far if it's running
+func (e TimeEntry) Duration() time.Duration {
+	stop := e.Stop
+	if e.Running() {
+		stop = time.Now()
+	}
+
+	return stop.Sub(e.Start)
+}
+
+// Time spent on a task over some period, for timesheets
+type TimeTotal struct {
+	ProjectId   int
+	ProjectName string
+	TaskId      int
+	TaskKey     string
+	TaskName    string
+	Seconds     int
+}
+
+// Entries stay with their task, and go when it's deleted
+func (t *TaskDB) CreateTimeTable() error {
+	_, err := t.db.Exec(`
+    CREATE TABLE IF NOT EXISTS time_entries (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        task_id INTEGER NOT NULL,
+        user_id INTEGER NOT NULL,
+        started_at TEXT NOT NULL,
+        stopped_at TEXT,
+        FOREIGN KEY (task_id) REFERENCES tasks (id),
+        FOREIGN KEY (user_id) REFERENCES users (id)
+    )
+    `)
+
+	return err
+}
+
+const deleteTimeEntries = "DELETE FROM time_entries WHERE task_id = ?"
+
+const timeEntrySelect = `SELECT time_entries.id, time_entries.task_id,
+    COALESCE(projects.prefix, ''), COALESCE(tasks.seq, 0), tasks.name,
+    time_entries.user_id, COALESCE(NULLIF(users.display_name, ''), users.name, ''),
+    time_entries.started_at, COALESCE(time_entries.stopped_at, '')
+    FROM time_entries
+    JOIN tasks ON tasks.id = time_entries.task_id
+    LEFT JOIN projects ON projects.id = tasks.project_id
+    LEFT JOIN users ON users.id = time_entries.user_id`
+
+func scanTimeEntry(row scanner) (TimeEntry, error) {
+	var entry TimeEntry
+	var task Task
+	var start, stop string
+	err := row.Scan(
+		&entry.Id,
+		&entry.TaskId,
+		&task.Prefix,
+		&task.Seq,
+		&entry.TaskName,
+		&entry.UserId,
+		&entry.UserName,
+		&start,
+		&stop,
+	)
+	if err != nil {
+		return entry, err
+	}
+
+	entry.TaskKey = task.Key()
+
+	if entry.Start, err = parseTimestamp(start); err != nil {
+		return entry, err
+	}
+
+	entry.Stop, err = parseTimestamp(stop)
+
+	return entry, err
+}
+
+// Start timing the task for the user, stopping whatever they were timing
+// before
+func (t *TaskDB) StartTimer(task int, user int) (TimeEntry, error) {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ?)", task).Scan(&exists)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	if !exists {
+		return TimeEntry{}, sql.ErrNoRows
+	}
+
+	_, err = tx.Exec(
+		"UPDATE time_entries SET stopped_at = datetime('now') WHERE user_id = ? AND stopped_at IS NULL",
+		user,
+	)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO time_entries (task_id, user_id, started_at) VALUES (?, ?, datetime('now'))",
+		task,
+		user,
+	)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return TimeEntry{}, err
+	}
+
+	return scanTimeEntry(t.db.QueryRow(timeEntrySelect+" WHERE time_entries.id = ?", id))
+}
+
+// Stop the user's running timer, and return the finished entry.
+// sql.ErrNoRows if there isn't one running.
+func (t *TaskDB) StopTimer(user int) (TimeEntry, error) {
+	entry, err := t.RunningTimer(user)
+	if err != nil {
+		return entry, err
+	}
+
+	_, err = t.db.Exec("UPDATE time_entries SET stopped_at = datetime('now') WHERE id = ?", entry.Id)
+	if err != nil {
+		return entry, err
+	}
+
+	return scanTimeEntry(t.db.QueryRow(timeEntrySelect+" WHERE time_entries.id = ?", entry.Id))
+}
+
+// sql.ErrNoRows if the user isn't timing anything
+func (t *TaskDB) RunningTimer(user int) (TimeEntry, error) {
+	return scanTimeEntry(t.db.QueryRow(
+		timeEntrySelect+" WHERE time_entries.user_id = ? AND time_entries.stopped_at IS NULL",
+		user,
+	))
+}
+
+// Everyone's time on the task, oldest first
+func (t *TaskDB) GetTimeEntries(task int) ([]TimeEntry, error) {
+	rows, err := t.db.Query(
+		timeEntrySelect+" WHERE time_entries.task_id = ? ORDER BY time_entries.started_at, time_entries.id",
+		task,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []TimeEntry
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Time spent on each task in entries started since the given time, by
+// project in board order. Running timers count up to now. A user of 0
+// means everyone.
+func (t *TaskDB) TimeReport(since time.Time, user int) ([]TimeTotal, error) {
+	rows, err := t.db.Query(
+		`SELECT projects.id, projects.name, COALESCE(projects.prefix, ''),
+            tasks.id, COALESCE(tasks.seq, 0), tasks.name,
+            SUM(CAST(strftime('%s', COALESCE(time_entries.stopped_at, datetime('now'))) AS INTEGER)
+                - CAST(strftime('%s', time_entries.started_at) AS INTEGER))
+        FROM time_entries
+        JOIN tasks ON tasks.id = time_entries.task_id
+        JOIN projects ON projects.id = tasks.project_id
+        WHERE time_entries.started_at >= ? AND (? = 0 OR time_entries.user_id = ?)
+        GROUP BY tasks.id
+        ORDER BY projects.sort_order, projects.id, tasks.seq, tasks.id`,
+		since.UTC().Format(timestampFormat),
+		user,
+		user,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []TimeTotal
+	for rows.Next() {
+		var total TimeTotal
+		var task Task
+		err := rows.Scan(
+			&total.ProjectId,
+			&total.ProjectName,
+			&task.Prefix,
+			&total.TaskId,
+			&task.Seq,
+			&total.TaskName,
+			&total.Seconds,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		total.TaskKey = task.Key()
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+// The user's running timer, or the zero entry if they aren't timing anything
+func runningTimer(user int) (TimeEntry, error) {
+	entry, err := store.GetRunningTimer(user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TimeEntry{}, nil
+	}
+
+	return entry, err
+}
+
+// Short and readable, e.g. "1h 05m" or "12m"
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+// The running timer, ticking, e.g. "1:05:09"
+func formatClock(d time.Duration) string {
+	seconds := int(d / time.Second)
+
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// Monday of this week, at midnight
+func startOfWeek() time.Time {
+	now := time.Now()
+	offset := (int(now.Weekday()) + 6) % 7
+
+	return time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, time.Local)
+}
+
+// `kanban-cli time report`, hours per project and task for timesheets
+func timeReport(args []string, user User) error {
+	flags := flag.NewFlagSet("time", flag.ExitOnError)
+	since := flags.String("since", startOfWeek().Format(dateFormat), "count time from this date on")
+	mine := flags.Bool("mine", false, "only count your own time")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: kanban-cli time report [--since YYYY-MM-DD] [--mine]")
+		flags.PrintDefaults()
+	}
+
+	if len(args) == 0 || args[0] != "report" {
+		flags.Usage()
+		return errors.New("unknown time command")
+	}
+
+	flags.Parse(args[1:])
+
+	from, err := parseDate(*since)
+	if err != nil || from.IsZero() {
+		return fmt.Errorf("--since must look like %s", dateFormat)
+	}
+
+	var userId int
+	if *mine {
+		userId = user.id
+	}
+
+	totals, err := store.GetTimeReport(from, userId)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Since %s\n", from.Format("Mon 2 Jan 2006"))
+
+	if len(totals) == 0 {
+		fmt.Println("\nNo time tracked")
+		return nil
+	}
+
+	var all int
+	for i, total := range totals {
+		// A line for each project, above its tasks
+		if i == 0 || total.ProjectId != totals[i-1].ProjectId {
+			var seconds int
+			for _, t := range totals[i:] {
+				if t.ProjectId != total.ProjectId {
+					break
+				}
+
+				seconds += t.Seconds
+			}
+
+			fmt.Printf("\n%-50s %s\n", total.ProjectName, hours(seconds))
+		}
+
+		fmt.Printf("  %-10s %-37s %s\n", total.TaskKey, truncate.StringWithTail(total.TaskName, 37, "…"), hours(total.Seconds))
+		all += total.Seconds
+	}
+
+	fmt.Printf("\n%-50s %s\n", "Total", hours(all))
+
+	return nil
+}
+
+// Decimal hours, the way timesheets want them
+func hours(seconds int) string {
+	return fmt.Sprintf("%7.2fh", float64(seconds)/3600)
+}
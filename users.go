@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+type User struct {
+	id          int
+	name        string
+	displayName string
+}
+
+// Name to show for the user, the display name if they have one
+func (u User) String() string {
+	if u.displayName != "" {
+		return u.displayName
+	}
+
+	return u.name
+}
+
+// Up to two initials, used as the user's avatar ("Jane Doe" -> "JD").
+// Single word names use their first two letters ("jake" -> "JA").
+func Initials(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var initials []rune
+	switch {
+	case len(words) == 0:
+		return "?"
+	case len(words) == 1:
+		initials = []rune(words[0])
+		if len(initials) > 2 {
+			initials = initials[:2]
+		}
+	default:
+		initials = []rune{[]rune(words[0])[0], []rune(words[len(words)-1])[0]}
+	}
+
+	return strings.ToUpper(string(initials))
+}
+
+type UserDB struct {
+	db *sql.DB
+}
+
+func (u *UserDB) CreateTable() error {
+	// Create our table if it doesn't exist.
+	// A user should have the following data:
+	// id
+	// name --> unique, e.g. a login name
+	// display_name
+	createStatement := `
+    CREATE TABLE IF NOT EXISTS users (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL UNIQUE,
+        display_name TEXT NOT NULL DEFAULT ''
+    )
+    `
+
+	_, err := u.db.Exec(createStatement)
+
+	return err
+}
+
+func (u *UserDB) GetAll() ([]User, error) {
+	rows, err := u.db.Query("SELECT id, name, display_name FROM users ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		err = rows.Scan(&user.id, &user.name, &user.displayName)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (u *UserDB) Get(id int) (User, error) {
+	var user User
+	err := u.db.QueryRow("SELECT id, name, display_name FROM users WHERE id = ?", id).
+		Scan(&user.id, &user.name, &user.displayName)
+
+	return user, err
+}
+
+func (u *UserDB) GetByName(name string) (User, error) {
+	var user User
+	err := u.db.QueryRow("SELECT id, name, display_name FROM users WHERE name = ?", name).
+		Scan(&user.id, &user.name, &user.displayName)
+
+	return user, err
+}
+
+// Find a user by name or display name, sql.ErrNoRows if there's no match.
+// This is how people are picked in forms and filters, which never add
+// anyone: users are only made by Ensure, when someone logs in or is added
+// through the API.
+func (u *UserDB) Find(name string) (User, error) {
+	var user User
+	err := u.db.QueryRow(
+		"SELECT id, name, display_name FROM users WHERE name = ? OR display_name = ? ORDER BY name = ? DESC",
+		name,
+		name,
+		name,
+	).Scan(&user.id, &user.name, &user.displayName)
+
+	return user, err
+}
+
+// Get the user with this name, adding them if they're new. A non-empty
+// display name replaces the stored one.
+func (u *UserDB) Ensure(name, displayName string) (User, error) {
+	user, err := u.GetByName(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = u.db.Exec("INSERT INTO users (name, display_name) VALUES(?, ?)", name, displayName)
+		if err != nil {
+			return user, err
+		}
+
+		return u.GetByName(name)
+	}
+
+	if err != nil {
+		return user, err
+	}
+
+	if displayName != "" && displayName != user.displayName {
+		_, err = u.db.Exec("UPDATE users SET display_name = ? WHERE id = ?", displayName, user.id)
+		user.displayName = displayName
+	}
+
+	return user, err
+}
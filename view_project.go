@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var projectDetailLabelStyle = lipgloss.NewStyle().
+	Foreground(grey).
+	Width(14)
+
+type ViewProject struct {
+	session *Session
+	width   int
+	height  int
+	project Project
+	counts  [numStatus]int
+	sprint  string
+	err     error // Why the details couldn't be loaded, reported when they open
+	help    help.Model
+	keys    viewProjectKeyMap
+}
+
+func NewViewProject(session *Session, width, height int, p Project) *ViewProject {
+	model := &ViewProject{
+		session: session,
+		width:   width,
+		height:  height,
+		project: p,
+		help:    help.New(),
+		keys:    viewProjectKeys,
+		sprint:  "none",
+	}
+
+	stats, err := store.GetProjectStats(p.id)
+	if err != nil {
+		model.err = err
+		return model
+	}
+
+	model.counts = stats.counts
+
+	sprints, err := store.GetSprints(p.id)
+	if err != nil {
+		model.err = err
+		return model
+	}
+
+	if current, ok := currentSprint(sprints); ok {
+		model.sprint = fmt.Sprintf("%s, %s (%s)", current.Name, current.Dates(), sprintSummary(current))
+	}
+
+	return model
+}
+
+func (v *ViewProject) Init() tea.Cmd {
+	if v.err != nil {
+		return reportError(v.err)
+	}
+
+	return nil
+}
+
+func (v *ViewProject) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mt := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = mt.Width
+		v.height = mt.Height
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(mt, v.keys.Open):
+			// In place of the details, so going back from either
+			// lands on the projects table
+			return v, Replace(NewBoard(v.session, v.project.id, v.width, v.height))
+		case key.Matches(mt, v.keys.Edit):
+			return v, Replace(EditProjectForm(v.session, v.project, v.width, v.height))
+		case key.Matches(mt, v.keys.Back):
+			return v, Pop()
+		case key.Matches(mt, v.keys.Quit):
+			return v, tea.Quit
+		}
+	}
+
+	return v, nil
+}
+
+// Describe how far away the target date is, e.g. "in 3 days"
+func targetDateView(target time.Time) string {
+	if target.IsZero() {
+		return "none"
+	}
+
+	days := daysBetween(time.Now(), target)
+
+	var when string
+	switch {
+	case days == 0:
+		when = "today"
+	case days == 1:
+		when = "tomorrow"
+	case days > 1:
+		when = fmt.Sprintf("in %d days", days)
+	default:
+		when = errorStyle.Render(fmt.Sprintf("%d days overdue", -days))
+	}
+
+	return fmt.Sprintf("%s (%s)", target.Format(dateFormat), when)
+}
+
+func (v *ViewProject) View() string {
+	color := projectColor(v.project.color)
+	name := nameStyle.Foreground(color).Render(v.project.name)
+
+	detail := func(label, value string) string {
+		return lipgloss.JoinHorizontal(lipgloss.Top, projectDetailLabelStyle.Render(label), value)
+	}
+
+	owner := v.project.owner
+	if owner == "" {
+		owner = "nobody"
+	}
+
+	tasks := fmt.Sprintf(
+		"%d todo, %d in progress, %d done",
+		v.counts[todo],
+		v.counts[inProgress],
+		v.counts[done],
+	)
+
+	details := lipgloss.JoinVertical(
+		lipgloss.Left,
+		name,
+		infoStyle.Render(v.project.description),
+		"",
+		detail("Key prefix", v.project.prefix),
+		detail("Owner", owner),
+		detail("Target date", targetDateView(v.project.targetDate)),
+		detail("Tasks", tasks),
+		detail("Sprint", v.sprint),
+		detail("Color", lipgloss.NewStyle().Foreground(color).Render("████")),
+	)
+
+	render := lipgloss.JoinVertical(
+		lipgloss.Center,
+		taskStyle.BorderForeground(color).Render(details),
+		v.help.View(v.keys),
+	)
+
+	return lipgloss.Place(v.width, v.height, lipgloss.Center, lipgloss.Center, render)
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -21,56 +30,594 @@ var nameStyle = lipgloss.NewStyle().
 var infoStyle = lipgloss.NewStyle().
 	Italic(true)
 
+var keyStyle = lipgloss.NewStyle().
+	Foreground(grey)
+
+var linkedTaskStyle = lipgloss.NewStyle().
+	Foreground(highlightColor).
+	Bold(true)
+
+// Only the latest time entries are listed, the total counts them all
+const maxTimeEntries = 5
+
+// Descriptions wrap at this width, or narrower if the window is
+const maxInfoWidth = 80
+
+// A task on its own, with the tasks blocking it and the ones it blocks,
+// and for an epic, the tasks in it. Those can be opened in turn, and
+// blockers added or removed.
 type ViewTask struct {
-	width  int
-	height int
-	task   Task
-	help   help.Model
-	keys   viewTaskKeyMap
+	session  *Session
+	width    int
+	height   int
+	task     Task
+	blockers []Task
+	blocking []Task
+	children []Task
+	info     viewport.Model // The description, rendered from Markdown
+	time     []TimeEntry
+	editing  Task // The task as it was when it was opened in the editor
+	revision int  // Revision of the data the task was loaded at
+	cursor   int  // Index into the blockers, the tasks it blocks, then its children
+	adding   bool // Typing in the key of a blocker to add
+	epic     bool // Typing in the key of the epic to put the task in instead
+	input    textinput.Model
+	confirm  Confirm
+	err      string
+	help     help.Model
+	keys     viewTaskKeyMap
 }
 
-func NewViewTask(width, height int, t Task) *ViewTask {
+func NewViewTask(session *Session, width, height int, t Task) *ViewTask {
 	model := &ViewTask{
-		width:  width,
-		height: height,
-		task:   t,
-		help:   help.New(),
-		keys:   viewTaskKeys,
+		session: session,
+		width:   width,
+		height:  height,
+		task:    t,
+		help:    help.New(),
+		keys:    viewTaskKeys,
 	}
 
+	// The editor would run on the server, for anyone connected to it
+	model.keys.Editor.SetEnabled(!session.ssh)
+
+	model.revision = currentRevision()
+	if err := model.loadLinks(); err != nil {
+		model.revision = -1
+		model.err = err.Error()
+	}
+	model.renderInfo()
+
 	return model
 }
 
+// Render the description to fit the window. Long ones scroll, taking up
+// to half the height.
+func (v *ViewTask) renderInfo() {
+	width := min(max(v.width-8, 20), maxInfoWidth)
+	content := renderMarkdown(v.task.Info, width)
+
+	offset := v.info.YOffset
+	v.info = viewport.New(width, min(lipgloss.Height(content), max(v.height/2, 3)))
+	v.info.SetContent(content)
+	v.info.SetYOffset(offset)
+}
+
+// Task descriptions are Markdown. If it can't be rendered, the text is
+// shown as it is.
+func renderMarkdown(text string, width int) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+
+	style := "light"
+	if lipgloss.HasDarkBackground() {
+		style = "dark"
+	}
+
+	r, err := glamour.NewTermRenderer(glamour.WithStandardStyle(style), glamour.WithWordWrap(width))
+	if err != nil {
+		return text
+	}
+
+	out, err := r.Render(text)
+	if err != nil {
+		return text
+	}
+
+	// Glamour pads the document with blank lines, which the box has
+	// room enough around it without
+	return strings.Trim(out, "\n")
+}
+
+func (v *ViewTask) loadLinks() error {
+	var err error
+
+	if v.blockers, err = store.GetBlockers(v.task.Id); err != nil {
+		return err
+	}
+
+	if v.blocking, err = store.GetBlocking(v.task.Id); err != nil {
+		return err
+	}
+
+	if v.time, err = store.GetTimeEntries(v.task.Id); err != nil {
+		return err
+	}
+
+	v.children = nil
+	if v.task.Epic {
+		if v.children, err = store.GetChildren(v.task.Id); err != nil {
+			return err
+		}
+	}
+
+	if n := len(v.linked()); v.cursor >= n {
+		v.cursor = max(n-1, 0)
+	}
+
+	return nil
+}
+
+// Blockers first, then the tasks this one blocks and the tasks in it, in the
+// order they're shown
+func (v ViewTask) linked() []Task {
+	return append(append(append([]Task{}, v.blockers...), v.blocking...), v.children...)
+}
+
 func (v ViewTask) Init() tea.Cmd {
 	return nil
 }
 
 func (v ViewTask) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch mt := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = mt.Width
+		v.height = mt.Height
+		v.renderInfo()
+	case RevisionMsg:
+		if mt.revision == v.revision {
+			return v, nil
+		}
+
+		// Someone else changed something, maybe this task
+		v.revision = mt.revision
+		task, err := store.GetTask(v.task.Id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return v, Pop()
+		}
+
+		if err != nil {
+			v.revision = -1
+			v.err = err.Error()
+			return v, nil
+		}
+
+		v.task = task
+		if err := v.loadLinks(); err != nil {
+			v.revision = -1
+			v.err = err.Error()
+		}
+		v.renderInfo()
+	case EpicChangedMsg:
+		v.reload()
+	case TaskFileMsg:
+		v.err = ""
+		if mt.err != nil {
+			v.err = mt.err.Error()
+			return v, nil
+		}
+
+		v.saveFile(mt.file)
 	case tea.KeyMsg:
+		if v.confirm.Open() {
+			var cmd tea.Cmd
+			v.confirm, cmd = v.confirm.Update(mt)
+			return v, cmd
+		}
+
+		if v.adding {
+			return v.updateAdding(mt)
+		}
+
+		v.err = ""
+		linked := v.linked()
+
 		switch {
 		case key.Matches(mt, v.keys.Back):
-			return models[board], nil
+			return v, Pop()
 		case key.Matches(mt, v.keys.Quit):
 			return v, tea.Quit
+		case key.Matches(mt, v.keys.Up):
+			if v.cursor > 0 {
+				v.cursor--
+			}
+		case key.Matches(mt, v.keys.Down):
+			if v.cursor < len(linked)-1 {
+				v.cursor++
+			}
+		case key.Matches(mt, v.keys.Editor):
+			// The task may be reloaded while the editor is open, but the
+			// changes are to this version of it
+			v.editing = v.task
+			return v, editTaskFile(taskFileOf(v.task))
+		case key.Matches(mt, v.keys.ScrollUp):
+			v.info.HalfViewUp()
+		case key.Matches(mt, v.keys.ScrollDown):
+			v.info.HalfViewDown()
+		case key.Matches(mt, v.keys.Open):
+			if len(linked) == 0 {
+				return v, nil
+			}
+
+			return v, Push(NewViewTask(v.session, v.width, v.height, linked[v.cursor]))
+		case key.Matches(mt, v.keys.AddBlocker):
+			v.startAdding("Blocked by (task key): ", false)
+			return v, textinput.Blink
+		case key.Matches(mt, v.keys.SetEpic):
+			if v.task.Epic {
+				return v, nil
+			}
+
+			v.startAdding("In epic (task key, empty for none): ", true)
+			v.input.SetValue(v.task.ParentKey)
+			v.input.CursorEnd()
+
+			return v, textinput.Blink
+		case key.Matches(mt, v.keys.ToggleEpic):
+			epic := !v.task.Epic
+			if !epic && len(v.children) > 0 {
+				prompt := fmt.Sprintf("%s won't be an epic any more, and its %s will be let go. Go ahead?", v.task.Key(), taskCount(len(v.children)))
+
+				var cmd tea.Cmd
+				v.confirm, cmd = Ask(prompt, changeEpic(v.task.Id, BulkChange{Epic: &epic}))
+				return v, cmd
+			}
+
+			return v, changeEpic(v.task.Id, BulkChange{Epic: &epic})
+		case key.Matches(mt, v.keys.RemoveBlocker):
+			// Only blockers can be removed from here. The tasks this
+			// one blocks are removed from their own view.
+			if v.cursor >= len(v.blockers) {
+				return v, nil
+			}
+
+			if err := store.RemoveBlocker(v.task.Id, v.blockers[v.cursor].Id); err != nil {
+				v.err = err.Error()
+				return v, nil
+			}
+
+			v.reload()
 		}
 	}
 
 	return v, nil
 }
 
+func (v ViewTask) updateAdding(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return v, tea.Quit
+	case "esc":
+		v.adding = false
+		v.err = ""
+		return v, nil
+	case "enter":
+		add := v.addBlocker
+		if v.epic {
+			add = v.setEpic
+		}
+
+		if err := add(strings.TrimSpace(v.input.Value())); err != nil {
+			v.err = err.Error()
+			return v, nil
+		}
+
+		v.adding = false
+		v.err = ""
+		v.reload()
+
+		return v, nil
+	}
+
+	var cmd tea.Cmd
+	v.input, cmd = v.input.Update(msg)
+
+	return v, cmd
+}
+
+func (v *ViewTask) startAdding(prompt string, epic bool) {
+	v.adding = true
+	v.epic = epic
+	v.err = ""
+	v.input = textinput.New()
+	v.input.Prompt = prompt
+	v.input.Focus()
+}
+
+// Save what came back from the editor. If someone else changed the task
+// since it was opened in the editor, nothing is saved.
+func (v *ViewTask) saveFile(f TaskFile) {
+	update := v.editing
+	update.Name = f.Title
+	update.Info = f.Info
+	update.Tags = f.Labels
+	update.Due = f.DueDate()
+
+	err := store.UpdateTask(update)
+	if errors.Is(err, ErrConflict) {
+		v.err = fmt.Sprintf("%s was changed while you were editing it, so your changes weren't saved", v.task.Key())
+		v.reload()
+		return
+	}
+
+	if err != nil {
+		v.err = err.Error()
+		return
+	}
+
+	v.reload()
+}
+
+// Sent once a task is made an epic, or not, or put in one
+type EpicChangedMsg struct{}
+
+func changeEpic(id int, change BulkChange) tea.Cmd {
+	return func() tea.Msg {
+		if err := store.UpdateTasks([]int{id}, change); err != nil {
+			return ErrorMsg{err: err}
+		}
+
+		return EpicChangedMsg{}
+	}
+}
+
+// Put the task in the epic with the key, or take it out of its epic if
+// the key is empty. Problems with the key come back as errors to show.
+func (v ViewTask) setEpic(ref string) error {
+	var parent int
+	if ref != "" {
+		if _, _, err := ParseTaskKey(ref); err != nil {
+			return err
+		}
+
+		epic, err := store.GetTaskByKey(ref)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("There's no task %s", strings.ToUpper(ref))
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !epic.Epic {
+			return fmt.Errorf("%s isn't an epic", epic.Key())
+		}
+
+		parent = epic.Id
+	}
+
+	return store.UpdateTasks([]int{v.task.Id}, BulkChange{Parent: &parent})
+}
+
+// Problems with the key come back as errors to show, as does anything else
+// that goes wrong
+func (v ViewTask) addBlocker(ref string) error {
+	if _, _, err := ParseTaskKey(ref); err != nil {
+		return err
+	}
+
+	blocker, err := store.GetTaskByKey(ref)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("There's no task %s", strings.ToUpper(ref))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = store.AddBlocker(v.task.Id, blocker.Id)
+	if errors.Is(err, ErrCycle) {
+		return fmt.Errorf("%s is already waiting on %s, directly or through other tasks", blocker.Key(), v.task.Key())
+	}
+
+	return err
+}
+
+// The task too, since its count of open blockers may have changed. If it
+// can't be read, what went wrong is shown instead.
+func (v *ViewTask) reload() {
+	// Read the revision first, so anything that changes while the task
+	// is loading gets picked up on the next check
+	v.revision = currentRevision()
+
+	task, err := store.GetTask(v.task.Id)
+	if err != nil {
+		v.revision = -1
+		v.err = err.Error()
+		return
+	}
+
+	v.task = task
+	if err := v.loadLinks(); err != nil {
+		v.revision = -1
+		v.err = err.Error()
+	}
+	v.renderInfo()
+}
+
+// One line per linked task, with the one under the cursor highlighted.
+// offset is where the tasks start in linked().
+func (v ViewTask) linkedView(title string, tasks []Task, offset int) string {
+	lines := []string{title}
+	for i, t := range tasks {
+		line := fmt.Sprintf("%s %s · %s", t.Key(), t.Name, t.Status)
+		if offset+i == v.cursor {
+			line = linkedTaskStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lipgloss.NewStyle().MarginTop(1).Render(strings.Join(lines, "\n"))
+}
+
+// The latest time entries, with the total and how much of it was whose
+func (v ViewTask) timeView() string {
+	var total time.Duration
+	var names []string
+	byName := make(map[string]time.Duration)
+	for _, e := range v.time {
+		total += e.Duration()
+		if _, ok := byName[e.UserName]; !ok {
+			names = append(names, e.UserName)
+		}
+		byName[e.UserName] += e.Duration()
+	}
+
+	lines := []string{"Time tracked " + formatDuration(total)}
+	if len(names) > 1 {
+		var totals []string
+		for _, name := range names {
+			totals = append(totals, fmt.Sprintf("%s %s", name, formatDuration(byName[name])))
+		}
+
+		lines = append(lines, keyStyle.Render("  "+strings.Join(totals, " · ")))
+	}
+
+	entries := v.time
+	if len(entries) > maxTimeEntries {
+		lines = append(lines, keyStyle.Render(fmt.Sprintf("  … and %d earlier", len(entries)-maxTimeEntries)))
+		entries = entries[len(entries)-maxTimeEntries:]
+	}
+
+	for _, e := range entries {
+		stop := "now"
+		if !e.Running() {
+			stop = e.Stop.Format("15:04")
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			"  %s %s–%s  %s  %s",
+			e.Start.Format("Mon 2 Jan"),
+			e.Start.Format("15:04"),
+			stop,
+			formatDuration(e.Duration()),
+			e.UserName,
+		))
+	}
+
+	return lipgloss.NewStyle().MarginTop(1).Render(strings.Join(lines, "\n"))
+}
+
 func (v ViewTask) View() string {
+	k := keyStyle.Render(v.task.Key())
 	n := nameStyle.Render(v.task.Name)
-	i := infoStyle.Render(v.task.Info)
+	lines := []string{k, n}
+
+	if v.task.Info != "" {
+		lines = append(lines, v.info.View())
+
+		// Only long descriptions scroll
+		if v.info.TotalLineCount() > v.info.Height {
+			scroll := fmt.Sprintf("%3.f%% · pgup/pgdn to scroll", v.info.ScrollPercent()*100)
+			lines = append(lines, helpStyle.Render(scroll))
+		}
+	}
+
+	var details []string
+	if !v.task.Due.IsZero() {
+		details = append(details, "Due "+targetDateView(v.task.Due))
+	}
+
+	if v.task.Recurrence != "" {
+		details = append(details, "Repeats "+v.task.Recurrence)
+	}
+
+	if v.task.Priority != noPriority {
+		details = append(details, "Priority "+v.task.Priority.String())
+	}
+
+	if v.task.Estimate != 0 {
+		details = append(details, "Estimated at "+formatEstimate(v.task.Estimate))
+	}
+
+	if len(v.task.Tags) > 0 {
+		details = append(details, "Tagged "+strings.Join(v.task.Tags, ", "))
+	}
+
+	if v.task.AssigneeName != "" {
+		details = append(details, "Assigned to "+v.task.AssigneeName)
+	}
+
+	if v.task.CreatedByName != "" {
+		details = append(details, "Created by "+v.task.CreatedByName)
+	}
+
+	if v.task.Epic {
+		details = append(details, "Epic, "+epicSummary(v.task))
+	}
+
+	if v.task.ParentKey != "" {
+		details = append(details, fmt.Sprintf("In epic %s %s", v.task.ParentKey, v.task.ParentName))
+	}
+
+	if len(details) > 0 {
+		d := keyStyle.MarginTop(1).Render(strings.Join(details, "\n"))
+		lines = append(lines, d)
+	}
+
+	if len(v.blockers) > 0 {
+		title := "Blocked by"
+		if v.task.Blocked() {
+			title = fmt.Sprintf("Blocked by (%d not done)", v.task.OpenBlockers)
+		}
+
+		lines = append(lines, v.linkedView(title, v.blockers, 0))
+	}
+
+	if len(v.blocking) > 0 {
+		lines = append(lines, v.linkedView("Blocks", v.blocking, len(v.blockers)))
+	}
+
+	if len(v.children) > 0 {
+		lines = append(lines, v.linkedView("Tasks in this epic", v.children, len(v.blockers)+len(v.blocking)))
+	}
+
+	if len(v.time) > 0 {
+		lines = append(lines, v.timeView())
+	}
+
 	taskData := taskStyle.Render(
-		lipgloss.JoinVertical(lipgloss.Left, n, i),
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
 	)
 
+	footer := v.help.View(v.keys)
+	if v.err != "" && !v.adding {
+		footer = errorStyle.Render(v.err) + "\n" + footer
+	}
+
+	if v.adding {
+		footer = v.input.View()
+		if v.err != "" {
+			footer += "\n" + errorStyle.Render(v.err)
+		}
+
+		footer += "\n" + helpStyle.Render("enter to save, esc to cancel")
+	}
+
 	render := lipgloss.JoinVertical(
 		lipgloss.Center,
 		taskData,
-		v.help.View(v.keys),
+		footer,
 	)
 
-	return lipgloss.Place(v.width, v.height, lipgloss.Center, lipgloss.Center, render)
+	view := lipgloss.Place(v.width, v.height, lipgloss.Center, lipgloss.Center, render)
+	if v.confirm.Open() {
+		return v.confirm.View(view, v.width, v.height)
+	}
+
+	return view
 }
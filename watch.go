@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often to check whether another process changed the data
+const refreshInterval = 2 * time.Second
+
+// Sent periodically with the current revision of the data. Views that show
+// data from the db keep the revision they loaded, and reload when it
+// changes.
+type RevisionMsg struct {
+	revision int
+}
+
+// Poll the revision and send it to the program, until the context is done.
+// It is sent every time rather than only on a change, so that a view coming
+// back into focus catches up on anything it missed while hidden.
+func watchRevision(ctx context.Context, p *tea.Program) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		revision, err := store.Revision()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		p.Send(RevisionMsg{revision: revision})
+	}
+}
+
+// The revision the data is at right now. If it can't be read, it's -1,
+// which no revision matches, so the view reloads on the next check.
+func currentRevision() int {
+	revision, err := store.Revision()
+	if err != nil {
+		log.Println(err)
+		return -1
+	}
+
+	return revision
+}